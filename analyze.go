@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// -analyze-middle：统计中间码的首位、前两位分布并标出疑似输错的中间码，只读不生成
+func analyzeMiddleCodes(codes []string) {
+	fmt.Fprintf(infoOut, "Middle code analysis: %d %d-digit codes\n", len(codes), layout.middleLen)
+	if len(codes) == 0 {
+		return
+	}
+
+	digits, _ := countByPrefix(codes, 1)
+	fmt.Fprintf(infoOut, "By first digit: %s\n", digits)
+	if layout.middleLen >= 2 {
+		pairs, distinct := countByPrefix(codes, 2)
+		fmt.Fprintf(infoOut, "By first two digits (%d distinct): %s\n", distinct, pairs)
+	}
+
+	var suspicious []string
+	for _, code := range codes {
+		if reason := suspiciousMiddle(code); reason != "" {
+			suspicious = append(suspicious, code+" ("+reason+")")
+		}
+	}
+	if len(suspicious) == 0 {
+		fmt.Fprintln(infoOut, "✅ No suspicious middle codes")
+		return
+	}
+	fmt.Fprintf(infoOut, "⚠️ %d suspicious middle codes, check them for typos:\n", len(suspicious))
+	for _, s := range suspicious {
+		fmt.Fprintf(infoOut, "  %s\n", s)
+	}
+}
+
+// 按前n位分组计数，输出如"05: 3, 10: 1"，按分组排序，同时返回分组数
+func countByPrefix(codes []string, n int) (string, int) {
+	counts := make(map[string]int)
+	for _, code := range codes {
+		counts[code[:n]]++
+	}
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = fmt.Sprintf("%s: %d", key, counts[key])
+	}
+	return strings.Join(parts, ", "), len(keys)
+}
+
+// 所有位相同（0000）或连续递增、递减（1234、9876）的中间码多半是占位或误填
+func suspiciousMiddle(code string) string {
+	if len(code) < 2 {
+		return ""
+	}
+	same, up, down := true, true, true
+	for i := 1; i < len(code); i++ {
+		diff := int(code[i]) - int(code[i-1])
+		same = same && diff == 0
+		up = up && diff == 1
+		down = down && diff == -1
+	}
+	switch {
+	case same:
+		return "all same digit"
+	case up || down:
+		return "sequential digits"
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// -append直接追加到已有文件，不经过临时文件；每次只写入完整的行并在写入期间持有文件排他锁，
+// 多个进程同时追加时行不会互相截断。这些参数会改写文件、写出不完整的行或不是按行输出
+var appendIncompatibleFlags = []string{"zip", "out-parallel", "header", "no-trailing-newline"}
+
+func checkAppendOptions(opts options) error {
+	if !opts.append {
+		return nil
+	}
+	for _, name := range appendIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-append cannot be used with -%s", name)
+		}
+	}
+	if opts.compress != "" && opts.compress != "none" {
+		return fmt.Errorf("-append cannot be used with -compress (concurrent gzip streams cannot be appended line by line)")
+	}
+	if isRemoteOutput(opts.out) {
+		return fmt.Errorf("-append needs a local -out file")
+	}
+	if formats, err := parseFormats(opts.format); err == nil {
+		for _, format := range formats {
+			if format == "binary" || format == "mask" || format == "ranges" {
+				return fmt.Errorf("-append cannot be used with -format=%s", format)
+			}
+		}
+	}
+	return nil
+}
+
+// 按行加锁的追加写入：缓存最后一个换行符之后的内容，每次在排他锁内一次写入所有完整的行，
+// 剩余的不完整行在flush时写入
+type lockedAppender struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       io.Writer // file，按-write-retries包装
+	pending []byte
+}
+
+func (a *lockedAppender) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	end := bytes.LastIndexByte(p, '\n')
+	if end < 0 {
+		a.pending = append(a.pending, p...)
+		return len(p), nil
+	}
+	lines := append(a.pending, p[:end+1]...)
+	if err := a.writeLocked(lines); err != nil {
+		return 0, err
+	}
+	a.pending = append(lines[:0], p[end+1:]...)
+	return len(p), nil
+}
+
+// 写入剩余的不完整行
+func (a *lockedAppender) flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.pending) == 0 {
+		return nil
+	}
+	err := a.writeLocked(a.pending)
+	a.pending = a.pending[:0]
+	return err
+}
+
+func (a *lockedAppender) writeLocked(data []byte) error {
+	if err := lockFile(a.file); err != nil {
+		return fmt.Errorf("failed to lock %s: %v", a.file.Name(), err)
+	}
+	_, err := a.w.Write(data)
+	if unlockErr := unlockFile(a.file); err == nil && unlockErr != nil {
+		err = fmt.Errorf("failed to unlock %s: %v", a.file.Name(), unlockErr)
+	}
+	return err
+}
+
+// 以追加方式打开输出文件（不存在时创建），appended表示文件中已有内容
+func appendOutput(path, format string, retries int) (*outputFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	o := &outputFile{format: format, path: path, file: file}
+	if info, err := file.Stat(); err == nil {
+		o.appended = info.Size() > 0
+	}
+	o.appender = &lockedAppender{file: file, w: file}
+	if retries > 0 {
+		o.appender.w = &retryWriter{w: file, name: path, retries: retries}
+	}
+	o.w = &countingWriter{w: o.appender}
+	return o, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 两个协程各自打开同一个文件追加，写入的块在行中间截断，文件中的每一行仍然完整
+func TestConcurrentAppendNoTornLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.txt")
+	middleCodes := []string{"0530", "0531", "0532", "0533", "0534"}
+	prefixes := []string{"137", "138"}
+	var wg sync.WaitGroup
+	errs := make([]error, len(prefixes))
+	for i, prefix := range prefixes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var numbers bytes.Buffer
+			if _, err := Generate(&numbers, []string{prefix}, middleCodes); err != nil {
+				errs[i] = err
+				return
+			}
+			o, err := appendOutput(path, "txt", 0)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			data := numbers.Bytes()
+			for len(data) > 0 {
+				// 块大小不是行长的整数倍
+				n := min(len(data), 97)
+				if _, err := o.w.Write(data[:n]); err != nil {
+					o.abort()
+					errs[i] = err
+					return
+				}
+				data = data[n:]
+			}
+			errs[i] = o.commit()
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 100000 {
+		t.Fatalf("got %d lines, want 100000", len(lines))
+	}
+	// 每个协程的行完整且保持各自的顺序
+	next := map[string]int{}
+	for _, line := range lines {
+		if len(line) != 11 || (line[:3] != "137" && line[:3] != "138") {
+			t.Fatalf("torn line %q", line)
+		}
+		i := next[line[:3]]
+		if want := fmt.Sprintf("%s%s%04d", line[:3], middleCodes[i/10000], i%10000); line != want {
+			t.Fatalf("got %q, want %q", line, want)
+		}
+		next[line[:3]] = i + 1
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// -auto-tune每种设置的试运行时间和最多生成的号码数，全部设置合计一两秒
+const (
+	autoTuneTrial  = 80 * time.Millisecond
+	autoTuneSample = 2_000_000
+)
+
+// 试运行的-batch-size候选值
+var autoTuneBatchSizes = []int{1024, 4096, 16384}
+
+func checkAutoTuneOptions(opts options) error {
+	if !opts.autoTune {
+		return nil
+	}
+	for _, name := range []string{"workers", "batch-size"} {
+		if opts.set[name] {
+			return fmt.Errorf("-auto-tune chooses -%s itself, remove one of them", name)
+		}
+	}
+	return nil
+}
+
+// 单个txt输出且没有按号码顺序或逐个号码处理的参数时才会使用-workers
+func parallelWritable(formats []string, opts options) bool {
+	return len(formats) == 1 && formats[0] == "txt" && opts.outParallel == "" &&
+		!opts.index && !opts.grouped && !opts.noPrefix && !opts.stats && opts.statsJSON == "" &&
+		opts.sample == 0 && !opts.interleave && !opts.sepFuzz
+}
+
+// -workers的候选值：1、2、4……直到CPU核数，并包含核数本身
+func autoTuneWorkers() []int {
+	cpus := runtime.NumCPU()
+	var workers []int
+	for n := 1; n < cpus; n *= 2 {
+		workers = append(workers, n)
+	}
+	return append(workers, cpus)
+}
+
+// 用每组-workers和-batch-size生成一小段号码并写入输出目录中的临时文件（远程输出时丢弃），
+// 返回吞吐量最高的设置
+func autoTune(prefixes, middleCodes []string, spaceOpts []Option, out string) (int, int, error) {
+	var w io.Writer = io.Discard
+	var file *os.File
+	if !isRemoteOutput(out) {
+		var err error
+		if file, err = os.CreateTemp(filepath.Dir(out), ".phonedict-tune-*"); err != nil {
+			return 0, 0, fmt.Errorf("failed to create file: %v", err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+		w = file
+	}
+	bestWorkers, bestBatch, bestRate := 1, autoTuneBatchSizes[1], -1.0
+	started := time.Now()
+	trials := 0
+	for _, workers := range autoTuneWorkers() {
+		batchSizes := autoTuneBatchSizes
+		if workers == 1 {
+			// 单个协程不分批，-batch-size不起作用
+			batchSizes = batchSizes[1:2]
+		}
+		for _, batchSize := range batchSizes {
+			if file != nil {
+				if err := file.Truncate(0); err != nil {
+					return 0, 0, fmt.Errorf("failed to write to file: %v", err)
+				}
+				if _, err := file.Seek(0, io.SeekStart); err != nil {
+					return 0, 0, fmt.Errorf("failed to write to file: %v", err)
+				}
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), autoTuneTrial)
+			trialOpts := append(append([]Option{}, spaceOpts...), WithLimit(autoTuneSample), WithContext(ctx))
+			begin := time.Now()
+			count, err := GenerateParallel(w, prefixes, middleCodes, workers, batchSize, trialOpts...)
+			elapsed := time.Since(begin)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				return 0, 0, err
+			}
+			trials++
+			rate := float64(count) / elapsed.Seconds()
+			if rate > bestRate {
+				bestWorkers, bestBatch, bestRate = workers, batchSize, rate
+			}
+		}
+	}
+	logf("🔧 Auto-tune: -workers=%d -batch-size=%d (%.1f million numbers/s, %d setting(s) tried in %.1fs)\n",
+		bestWorkers, bestBatch, bestRate/1e6, trials, time.Since(started).Seconds())
+	return bestWorkers, bestBatch, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// -format=binary：每个号码按无符号整数写成8字节小端序，没有分隔符和文件头，
+// 文件大小恰好为号码数的8倍；号码的位数由读取方按-prefix-len等参数补零还原
+const binaryRecordSize = 8
+
+// 二进制格式只能保存纯数字的号码，且完整号码不能超过uint64的19位
+var binaryIncompatibleFlags = []string{"template", "separator-fuzz", "output-base", "index"}
+
+func checkBinaryOptions(formats []string, opts options) error {
+	hasBinary := false
+	for _, format := range formats {
+		hasBinary = hasBinary || format == "binary"
+	}
+	if !hasBinary {
+		return nil
+	}
+	for _, name := range binaryIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-format=binary cannot be used with -%s (numbers are stored as plain integers)", name)
+		}
+	}
+	if n := opts.layout.prefixLen + opts.layout.middleLen + opts.layout.suffixLen; n > 19 {
+		return fmt.Errorf("-format=binary supports numbers of up to 19 digits, got %d", n)
+	}
+	return nil
+}
+
+// 把纯数字号码追加为8字节小端序整数
+func appendBinaryNumber(dst []byte, number string) []byte {
+	n, _ := strconv.ParseUint(number, 10, 64)
+	return binary.LittleEndian.AppendUint64(dst, n)
+}
+
+// 扩展名为.bin的文件按二进制格式读取
+func isBinaryNumbersFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".bin")
+}
+
+// 逐个读取二进制号码，用法与bufio.Scanner相同，Text返回补零到layout.totalLen()位的号码
+type binaryNumberReader struct {
+	r      *bufio.Reader
+	buf    [binaryRecordSize]byte
+	number string
+	err    error
+}
+
+func newBinaryNumberReader(r io.Reader) *binaryNumberReader {
+	return &binaryNumberReader{r: bufio.NewReader(r)}
+}
+
+func (b *binaryNumberReader) Scan() bool {
+	if b.err != nil {
+		return false
+	}
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			b.err = fmt.Errorf("truncated record, the file size is not a multiple of %d bytes", binaryRecordSize)
+		} else if err != io.EOF {
+			b.err = err
+		}
+		return false
+	}
+	b.number = fmt.Sprintf("%0*d", layout.totalLen(), binary.LittleEndian.Uint64(b.buf[:]))
+	return true
+}
+
+func (b *binaryNumberReader) Text() string {
+	return b.number
+}
+
+func (b *binaryNumberReader) Err() error {
+	return b.err
+}
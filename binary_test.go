@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	prefixes := []string{"137", "189"}
+	middleCodes := []string{"0537", "0001"}
+	var text, bin bytes.Buffer
+	n, err := GenerateMulti([]Output{{W: &text}, {W: &bin, Binary: true}}, prefixes, middleCodes, WithSuffixRange(0, 999, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bin.Len() != int(n)*binaryRecordSize {
+		t.Fatalf("binary output is %d bytes for %d numbers", bin.Len(), n)
+	}
+	want := strings.Fields(text.String())
+	r := newBinaryNumberReader(&bin)
+	var got []string
+	for r.Scan() {
+		got = append(got, r.Text())
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("read back %d numbers that differ from the %d written", len(got), len(want))
+	}
+}
+
+func TestBinaryKnownNumbers(t *testing.T) {
+	var buf []byte
+	for _, number := range []string{"13705370000", "00000000001", "99999999999"} {
+		buf = appendBinaryNumber(buf, number)
+	}
+	// 13705370000 = 0x0330E75990，小端序
+	if want := []byte{0x90, 0x59, 0xe7, 0x30, 0x03, 0, 0, 0}; !bytes.Equal(buf[:8], want) {
+		t.Fatalf("13705370000 encoded as % x, want % x", buf[:8], want)
+	}
+	r := newBinaryNumberReader(bytes.NewReader(buf))
+	for _, want := range []string{"13705370000", "00000000001", "99999999999"} {
+		if !r.Scan() || r.Text() != want {
+			t.Fatalf("read %q, want %s", r.Text(), want)
+		}
+	}
+	if r.Scan() || r.Err() != nil {
+		t.Fatalf("unexpected record or error %v at the end", r.Err())
+	}
+}
+
+func TestBinaryTruncatedRecord(t *testing.T) {
+	r := newBinaryNumberReader(bytes.NewReader(appendBinaryNumber(nil, "13705370000")[:5]))
+	if r.Scan() {
+		t.Fatal("truncated record was read")
+	}
+	if r.Err() == nil || !strings.Contains(r.Err().Error(), "truncated") {
+		t.Fatalf("got error %v, want a truncated record error", r.Err())
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 解析-blocklist-regex：逗号分隔的多个正则表达式，合并为一个表达式只编译一次。
+// 花括号内的逗号属于重复次数（如{2,4}），不作为分隔符；\,表示字面的逗号
+func parseBlocklist(input string) (*regexp.Regexp, error) {
+	var patterns []string
+	var current strings.Builder
+	depth := 0
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		switch {
+		case ch == '\\' && i+1 < len(input):
+			current.WriteByte(ch)
+			current.WriteByte(input[i+1])
+			i++
+			continue
+		case ch == '{':
+			depth++
+		case ch == '}' && depth > 0:
+			depth--
+		case ch == ',' && depth == 0:
+			patterns = append(patterns, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(ch)
+	}
+	patterns = append(patterns, current.String())
+
+	var groups []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("invalid -blocklist-regex pattern %q: %v", pattern, err)
+		}
+		groups = append(groups, "(?:"+pattern+")")
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("invalid -blocklist-regex: no patterns given")
+	}
+	return regexp.MustCompile(strings.Join(groups, "|")), nil
+}
+
+// 丢弃与任一模式匹配的号码，匹配的是模板格式化后写出的号码
+func blocklistFilter(re *regexp.Regexp) Filter {
+	return func(number string) bool {
+		return !re.MatchString(number)
+	}
+}
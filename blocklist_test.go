@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseBlocklist(t *testing.T) {
+	re, err := parseBlocklist(`^1[0-9]{2}0000, 8888$ ,x\,y`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for number, blocked := range map[string]bool{
+		"13700001234": true,
+		"13705378888": true,
+		"x,y":         true,
+		"13705370000": false,
+		"13788880001": false,
+	} {
+		if re.MatchString(number) != blocked {
+			t.Errorf("%s blocked = %v, want %v", number, !blocked, blocked)
+		}
+	}
+	for _, input := range []string{"", " , ", "([0-9]"} {
+		if _, err := parseBlocklist(input); err == nil {
+			t.Errorf("parseBlocklist(%q) was accepted", input)
+		}
+	}
+}
+
+// ^1[0-9]{2}0000屏蔽中间码0000的全部号码，8888$屏蔽每个中间码的一个尾号
+func TestBlocklistFilter(t *testing.T) {
+	re, err := parseBlocklist("^1[0-9]{2}0000,8888$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137", "138"}, []string{"0000", "0537"}, WithFilter(blocklistFilter(re)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2*9999 {
+		t.Fatalf("got %d numbers, want %d", n, 2*9999)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if line[3:7] == "0000" || strings.HasSuffix(line, "8888") {
+			t.Fatalf("blocked number %s was written", line)
+		}
+	}
+	for _, prefix := range []string{"137", "138"} {
+		if !strings.Contains(buf.String(), fmt.Sprintf("%s05378887\n%s05378889\n", prefix, prefix)) {
+			t.Errorf("numbers next to the blocked %s05378888 are missing", prefix)
+		}
+	}
+}
+
+// 过滤后各部分的号码数事先未知，-index不能跨-out-parallel的文件连续
+func TestOutParallelIndexRejectsFilters(t *testing.T) {
+	for _, flag := range []string{"-checksum", "-blocklist-regex", "-dedup-against", "-complement"} {
+		value := map[string]string{"-checksum": "luhn", "-blocklist-regex": "0000$"}[flag]
+		if value == "" {
+			value = "numbers.txt"
+		}
+		_, _, err := parseArgs([]string{"-out-parallel", "a.txt,b.txt", "-index", flag, value})
+		if err == nil || !strings.Contains(err.Error(), "-index with -out-parallel") || !strings.Contains(err.Error(), flag) {
+			t.Errorf("-index -out-parallel %s returned %v", flag, err)
+		}
+	}
+	if _, _, err := parseArgs([]string{"-out-parallel", "a.txt,b.txt", "-blocklist-regex", "0000$"}); err != nil {
+		t.Errorf("-blocklist-regex without -index was rejected: %v", err)
+	}
+}
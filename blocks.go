@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Block 运营商实际分配的号段块：号段+中间码下的一段尾号，Middle为空表示适用于该号段的所有中间码
+type Block struct {
+	Prefix      string `json:"prefix"`
+	Middle      string `json:"middle,omitempty"`
+	SuffixStart int    `json:"suffixStart"`
+	SuffixEnd   int    `json:"suffixEnd"`
+}
+
+// 尾号闭区间
+type suffixBlockRange struct {
+	start, end int
+}
+
+// 按号段和中间码索引的分配块
+type blockSet struct {
+	byPair   map[string][]suffixBlockRange // prefix+middle
+	byPrefix map[string][]suffixBlockRange // 未指定中间码的块
+}
+
+// WithBlocks 只生成落在分配块内的号码，没有任何块覆盖的号段+中间码组合不生成
+func WithBlocks(blocks []Block) Option {
+	set := &blockSet{byPair: make(map[string][]suffixBlockRange), byPrefix: make(map[string][]suffixBlockRange)}
+	for _, b := range blocks {
+		r := suffixBlockRange{b.SuffixStart, b.SuffixEnd}
+		if b.Middle == "" {
+			set.byPrefix[b.Prefix] = append(set.byPrefix[b.Prefix], r)
+		} else {
+			set.byPair[b.Prefix+b.Middle] = append(set.byPair[b.Prefix+b.Middle], r)
+		}
+	}
+	return func(c *genConfig) {
+		c.blocks = set
+	}
+}
+
+// 号段+中间码可用的尾号区间，重叠或相邻的区间合并，按起点排序
+func (s *blockSet) ranges(prefix, middle string) []suffixBlockRange {
+	all := append(append([]suffixBlockRange(nil), s.byPrefix[prefix]...), s.byPair[prefix+middle]...)
+	if len(all) == 0 {
+		return nil
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].start < all[j].start })
+	merged := all[:1]
+	for _, r := range all[1:] {
+		last := &merged[len(merged)-1]
+		if r.start <= last.end+1 {
+			last.end = max(last.end, r.end)
+		} else {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// 读取-block-file：{"blocks":[{"prefix":"137","middle":"0537","suffixStart":0,"suffixEnd":4999}]}。
+// 格式错误或区间无效时报错，未知号段和未配置的中间码打印警告后跳过
+func loadBlockFile(path string, segments Segments, middleCodes []string) ([]Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	var file struct {
+		Blocks []Block `json:"blocks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, invalidInput(fmt.Errorf("failed to parse %s: %v", path, err))
+	}
+
+	known := segments.Operators()
+	configured := make(map[string]bool)
+	for _, code := range middleCodes {
+		configured[code] = true
+	}
+	maxSuffix := pow10(layout.suffixLen) - 1
+	middleRegex := layout.middleRegex()
+	var blocks []Block
+	for i, b := range file.Blocks {
+		if b.SuffixStart < 0 || b.SuffixEnd > maxSuffix || b.SuffixStart > b.SuffixEnd {
+			return nil, invalidInput(fmt.Errorf("%s: block %d has invalid suffix range %d-%d (must be within 0-%d)", path, i+1, b.SuffixStart, b.SuffixEnd, maxSuffix))
+		}
+		if b.Middle != "" && !middleRegex.MatchString(b.Middle) {
+			return nil, invalidInput(fmt.Errorf("%s: block %d has invalid middle code %q (must be a %d-digit number)", path, i+1, b.Middle, layout.middleLen))
+		}
+		switch {
+		case known[b.Prefix] == "":
+			logf("Warning: block %d in %s uses unknown or unselected prefix %s, skipped\n", i+1, path, b.Prefix)
+		case b.Middle != "" && !configured[b.Middle]:
+			logf("Warning: block %d in %s uses middle code %s which is not configured, skipped\n", i+1, path, b.Middle)
+		default:
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, nil
+}
+
+// 按号段打印分配块的覆盖率：块内号码数占该号段全部组合的比例
+func printBlockCoverage(prefixes []string, perPrefix map[string]int64, fullPerPrefix map[string]int64) {
+	fmt.Fprintln(infoOut, "Block coverage per prefix:")
+	skipped := 0
+	for _, prefix := range prefixes {
+		if perPrefix[prefix] == 0 {
+			skipped++
+			continue
+		}
+		fmt.Fprintf(infoOut, "  %s: %d of %d numbers (%.1f%%)\n", prefix, perPrefix[prefix], fullPerPrefix[prefix],
+			float64(perPrefix[prefix])*100/float64(fullPerPrefix[prefix]))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(infoOut, "  %d prefixes have no blocks and generate no numbers\n", skipped)
+	}
+}
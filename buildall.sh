@@ -8,7 +8,7 @@ build() {
     local OUTPUT=$3
     echo -e "\n=== Start compiling $GOOS/$GOARCH target ==="
     # Temporarily set environment variables and execute compilation (CGO_ENABLED=0 disables CGO to ensure cross-platform compatibility)
-    GOOS=$GOOS GOARCH=$GOARCH CGO_ENABLED=0 go build -o $OUTPUT main.go
+    GOOS=$GOOS GOARCH=$GOARCH CGO_ENABLED=0 go build -o $OUTPUT .
     # Check compilation result
     if [ $? -eq 0 ]; then
         echo "✅ Compilation successful: $OUTPUT (File size: $(du -sh $OUTPUT | cut -f1))"
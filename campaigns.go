@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// 分组的输出路径：在-out的扩展名前加上_分组名，如phonedict.txt -> phonedict_north.txt
+func campaignPath(out, name string) string {
+	ext := filepath.Ext(out)
+	return strings.TrimSuffix(out, ext) + "_" + name + ext
+}
+
+// config.json定义了campaigns时，每个分组用自己的中间码单独生成一个文件，最后汇总各文件的数量
+func generateCampaigns(segments Segments, config Config, opts options) error {
+	type result struct {
+		path  string
+		count int64
+	}
+	var results []result
+	for _, name := range config.campaignNames() {
+		campaignConfig := config
+		campaignConfig.MiddleCodes = config.Campaigns[name]
+		campaignOpts := opts
+		campaignOpts.out = campaignPath(opts.out, name)
+		if opts.report != "" {
+			campaignOpts.report = campaignPath(opts.report, name)
+		}
+		if opts.statsJSON != "" {
+			campaignOpts.statsJSON = campaignPath(opts.statsJSON, name)
+		}
+		logf("\n📦 Campaign %s: %d middle codes %v -> %s\n", name, len(campaignConfig.MiddleCodes), campaignConfig.MiddleCodes, campaignOpts.out)
+		count, err := generatePhoneNumbers(segments, campaignConfig, campaignOpts)
+		if err != nil {
+			return fmt.Errorf("campaign %s: %w", name, err)
+		}
+		results = append(results, result{campaignOpts.out, count})
+	}
+	logf("\nCampaign summary:\n")
+	for _, r := range results {
+		logf("  %s: %d numbers\n", r.path, r.count)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 解析-checksum：luhn为Luhn校验，mod:N为各位数字之和能被N整除，
+// 返回的校验函数会跳过号码中的非数字字符（如模板中的分隔符）
+func parseChecksum(spec string) (Filter, error) {
+	if spec == "luhn" {
+		return luhnValid, nil
+	}
+	if rest, ok := strings.CutPrefix(spec, "mod:"); ok {
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 2 {
+			return nil, fmt.Errorf("invalid -checksum %q (mod:N needs N >= 2)", spec)
+		}
+		return func(number string) bool {
+			return digitSum(number)%n == 0
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid -checksum %q (must be luhn or mod:N)", spec)
+}
+
+// Luhn校验：从最右一位开始，偶数位乘2（大于9减9），总和能被10整除即通过
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			continue
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func digitSum(number string) int {
+	sum := 0
+	for i := 0; i < len(number); i++ {
+		if d := int(number[i] - '0'); d >= 0 && d <= 9 {
+			sum += d
+		}
+	}
+	return sum
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLuhnValid(t *testing.T) {
+	for _, tc := range []struct {
+		number string
+		want   bool
+	}{
+		{"79927398713", true},
+		{"4111111111111111", true},
+		{"4539578763621486", true},
+		{"799-2739-8713", true},
+		{"79927398710", false},
+		{"4111111111111112", false},
+		{"13705370000", false},
+	} {
+		if got := luhnValid(tc.number); got != tc.want {
+			t.Errorf("luhnValid(%q) = %v, want %v", tc.number, got, tc.want)
+		}
+	}
+}
+
+func TestChecksumFilterPassRate(t *testing.T) {
+	// 最后一位是校验位，每10个连续尾号恰好有一个通过Luhn校验
+	filter, err := parseChecksum("luhn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passed := 0
+	err = ForEachNumber([]string{"137"}, []string{"0537"}, func(number string) error {
+		passed++
+		return nil
+	}, WithFilter(filter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed != 1000 {
+		t.Fatalf("%d numbers passed luhn, want 1000", passed)
+	}
+}
+
+func TestParseChecksum(t *testing.T) {
+	filter, err := parseChecksum("mod:7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filter("13705370002") || filter("13705370003") {
+		t.Fatal("mod:7 does not check the digit sum")
+	}
+	for _, spec := range []string{"mod:1", "mod:x", "crc"} {
+		if _, err := parseChecksum(spec); err == nil {
+			t.Errorf("parseChecksum(%q) accepted", spec)
+		}
+	}
+}
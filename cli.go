@@ -0,0 +1,731 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 命令行参数，未指定子命令和模式时进入交互菜单
+type options struct {
+	validateFile  string
+	matchedOut    string
+	operatorLabel string
+	sortDedup     string
+	chunkLines    int
+	layout        fieldLayout
+	force         bool
+	middle        string
+	middleCSV     string
+	province      string
+	out           string
+	shuffle       bool
+	shuffleSuffix bool
+	suffixSeed    int64                       // -shuffle-suffix实际使用的种子，由generatePhoneNumbers确定
+	suffixRanges  map[string]suffixBlockRange // 配置中operatorSuffixRanges展开到各号段的尾号范围
+	seed          int64
+	dailySeed     bool
+	sample        int64
+	weights       string
+	verbose       bool
+	header        bool
+	serve         string
+	noTrailingNL  bool
+	dedupAgainst  string
+	complement    string
+	deltaAgainst  string
+	deltaOut      string
+	seedFile      string
+	report        string
+	stats         bool
+	statsJSON     string
+	prefixSample  int
+	prefixFile    string
+	mergePrefixes bool
+	outputBase    int
+	stallRate     float64
+	stallWindow   time.Duration
+	timeout       time.Duration
+	dedupMode     string
+	dedupFPRate   float64
+	interleave    bool
+	sorted        bool
+	listPrefixes  bool
+	selfTest      bool
+	configSchema  bool
+	format        string
+	template      string
+	checksum      string
+	blocklist     string
+	logFile       string
+	defaultMiddle string
+	reverseSuffix bool
+	sepFuzz       bool
+	perPair       int
+	jsonErrors    bool
+	configFile    string
+	noAutoCreate  bool
+	strictMiddle  bool
+	workdir       string
+	shard         string
+	printConfig   bool
+	lint          bool
+	analyzeMiddle bool
+	writeRetries  int
+	outParallel   string
+	index         bool
+	grouped       bool
+	noPrefix      bool
+	zip           string
+	append        bool
+	suffixDigits  string
+	suffixLens    string
+	e164          bool
+	indexStart    int64
+	indexDelim    string
+	keys          *keyControl // 交互模式在终端中运行时的键盘控制
+	operators     string
+	limit         int64
+	compress      string
+	blockFile     string
+	preview       bool
+	diff          string
+	emitRate      string
+	densityReport string
+	workers       int
+	batchSize     int
+	autoTune      bool
+	maxMemory     string
+	suffixStart   int
+	suffixEnd     int
+	suffixStep    int
+	set           map[string]bool // 命令行中显式指定的参数
+	args          []string
+}
+
+// 子命令，每个子命令有独立的参数集合
+type command struct {
+	name    string
+	usage   string
+	summary string
+	flags   func(fs *flag.FlagSet, opts *options)
+	run     func(segments Segments, opts options) error
+}
+
+var commands = []command{
+	{
+		name:    "generate",
+		usage:   "generate [flags]",
+		summary: "generate the dictionary (default phonedict.txt) without the interactive menu",
+		flags: func(fs *flag.FlagSet, opts *options) {
+			registerMiddleFlags(fs, opts)
+			registerGenerateFlags(fs, opts)
+		},
+		run: runGenerate,
+	},
+	{
+		name:    "count",
+		usage:   "count [flags]",
+		summary: "print the estimated number count and output size without writing anything",
+		flags:   registerMiddleFlags,
+		run:     runCount,
+	},
+	{
+		name:    "validate",
+		usage:   "validate [flags] numbers.txt",
+		summary: "check which numbers in a file can be generated by the current prefixes and middle codes",
+		flags: func(fs *flag.FlagSet, opts *options) {
+			registerMiddleFlags(fs, opts)
+			fs.StringVar(&opts.matchedOut, "matched-out", "", "write matched numbers to `file`")
+		},
+		run: runValidate,
+	},
+}
+
+// 所有模式共用的参数
+func registerCommonFlags(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.configFile, "config", "config.json", "config `file`; .yaml/.yml and .toml are read as YAML and TOML, anything else as JSON. "+
+		"Without -config, config.yaml, config.yml or config.toml is used when config.json does not exist")
+	fs.StringVar(&opts.workdir, "workdir", "", "read and write the config, output and log files in `dir` (created if missing); "+
+		"all relative paths are resolved against it, so concurrent runs can use separate directories")
+	fs.BoolVar(&opts.strictMiddle, "strict-middle", false, "fail with an error listing every invalid middle code in the config file, -middle, "+
+		"NG_MIDDLE_CODES or manual input, instead of skipping them with a warning (for CI)")
+	fs.BoolVar(&opts.noAutoCreate, "no-auto-create", false, "fail with a config error instead of creating a sample config file when it does not exist (for read-only or CI environments)")
+	fs.BoolVar(&opts.jsonErrors, "json-errors", false, "report fatal errors as a JSON object {\"error\",\"kind\"} on stderr; "+
+		"the exit code is 1 (generate), 2 (config) or 3 (input) either way")
+	fs.BoolVar(&opts.verbose, "v", false, "verbose output, e.g. the number count of every middle code after generation")
+	fs.StringVar(&opts.operatorLabel, "operator-label", "en", "operator label `style`: code (CM/CU/CT), en (China Mobile) or cn (中国移动)")
+	fs.StringVar(&opts.operators, "operators", "", "comma-separated `operators` to use: mobile, unicom, telecom (default: $NG_OPERATORS, otherwise all)")
+	fs.StringVar(&opts.prefixFile, "prefix-file", "", "read the prefixes from `file` instead of the built-in list, one per line, "+
+		"optionally followed by an operator (e.g. 170,mobile); built-in prefixes without an operator keep theirs")
+	fs.BoolVar(&opts.mergePrefixes, "merge-prefixes", false, "with -prefix-file, add the file's prefixes to the built-in ones instead of replacing them")
+	fs.IntVar(&opts.layout.prefixLen, "prefix-len", 3, "number of digits in the operator prefix")
+	fs.IntVar(&opts.layout.middleLen, "middle-len", 4, "number of digits in the middle code")
+	fs.IntVar(&opts.layout.suffixLen, "suffix-len", 4, "number of digits in the suffix (range 0 to 10^n-1, zero-padded)")
+	fs.IntVar(&opts.suffixStart, "suffix-start", 0, "first suffix of every prefix and middle code")
+	fs.IntVar(&opts.suffixEnd, "suffix-end", -1, "last suffix, inclusive (default: 10^n-1 for -suffix-len n)")
+	fs.StringVar(&opts.suffixLens, "suffix-len-range", "", "generate every suffix length in `min-max`, e.g. 3-5 for 10-, 11- and 12-digit numbers, "+
+		"each with its full zero-padded suffix space (test data for variable-length handling)")
+	fs.StringVar(&opts.suffixDigits, "suffix-digits", "", "only use these `digits` in every suffix position, e.g. 08 for lucky numbers "+
+		"(len(digits)^n suffixes per combination; replaces -suffix-start/-suffix-end/-suffix-step)")
+	fs.IntVar(&opts.suffixStep, "suffix-step", 1, "increment between suffixes, e.g. 10 keeps every tenth suffix")
+	fs.StringVar(&opts.shard, "shard", "", "only suffixes where suffix % M == R, given as `R/M` (e.g. 0/4); running every R from 0 to M-1 covers all numbers once")
+	fs.IntVar(&opts.perPair, "per-pair", 0, "only the first `k` suffixes of every prefix and middle code (default: all)")
+}
+
+func registerMiddleFlags(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.middle, "middle", "", "comma-separated middle `codes`, wildcards like 05* or 0?37 and ranges like 0100-0120 are allowed (default: $NG_MIDDLE_CODES, otherwise config.json)")
+	registerDefaultMiddleFlag(fs, opts)
+	fs.StringVar(&opts.middleCSV, "middle-csv", "", "read middle codes and region names from a `csv` file with rows middle,region")
+	fs.StringVar(&opts.province, "province", "", "use the area codes of comma-separated `provinces` (e.g. Beijing,Shandong or 北京) from the built-in table as middle codes")
+}
+
+func registerDefaultMiddleFlag(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.defaultMiddle, "default-middle", "", "middle `codes` used when middleCodes in config.json is empty (default: defaultMiddleCodes in config.json, otherwise an error)")
+}
+
+func registerGenerateFlags(fs *flag.FlagSet, opts *options) {
+	fs.BoolVar(&opts.force, "force", false, "generate even if the estimated output size exceeds the free disk space")
+	fs.StringVar(&opts.out, "out", "phonedict.txt", "output `file` path (with several formats the extension is replaced per format)")
+	fs.BoolVar(&opts.index, "index", false, "number the output lines: txt lines become index,number, csv gets a leading index column and jsonl an index field; "+
+		"the index continues across -seed-file numbers and -out-parallel files")
+	fs.Int64Var(&opts.indexStart, "index-start", 1, "first index for -index")
+	fs.StringVar(&opts.indexDelim, "index-delim", ",", "separator between index and number in txt output with -index")
+	fs.StringVar(&opts.zip, "zip", "", "write the output file(s) as entries of a zip `archive` (e.g. out.zip) with a manifest.json describing the run; "+
+		"-out only names the entries")
+	fs.BoolVar(&opts.append, "append", false, "append to the output file(s) instead of replacing them; complete lines are written under an exclusive file lock "+
+		"(flock on Unix, LockFileEx on Windows), so several processes can append to the same file")
+	fs.StringVar(&opts.outParallel, "out-parallel", "", "comma-separated output `paths` (e.g. on different disks); prefixes are split into contiguous parts of similar size, "+
+		"each written by its own goroutine, so concatenating the files in order gives the -out output")
+	fs.BoolVar(&opts.preview, "preview", false, "print the first and last 5 numbers that would be generated, with their operator, and exit without writing")
+	fs.StringVar(&opts.diff, "diff", "", "compare the numbers this configuration would generate with an existing dictionary `file` and print how many are added, "+
+		"removed and unchanged, without writing anything (-dedup-mode bloom for large files)")
+	fs.StringVar(&opts.densityReport, "density-report", "", "write the estimated number count per province (province,middle_codes,estimated_count) to a CSV `file`, "+
+		"sorted by count with a total row, without generating numbers")
+	fs.StringVar(&opts.emitRate, "emit-rate", "", "stream numbers to stdout at a fixed `rate` such as 10/s, 30/m or 100/h instead of writing a file, "+
+		"for feeding a live, rate-limited consumer through a pipe; all other messages go to stderr")
+	fs.BoolVar(&opts.printConfig, "print-config", false, "print the effective settings (config file merged with flags and defaults) as JSON on stdout (other messages go to stderr) and exit without generating")
+	fs.StringVar(&opts.format, "format", "txt", "comma-separated output `formats` written in one pass: txt, csv, jsonl, binary (8-byte little-endian integers, read back by validate from a .bin file), mask (one hashcat mask such as 1370537?d?d?d?d per prefix and middle code, used alone) "+
+		"or ranges (one line such as 137-0537 → 13705370000-13705379999 per prefix and middle code, used alone)")
+	fs.StringVar(&opts.template, "template", defaultTemplate, "number `template` with fields {prefix}, {middle}, {suffix} or {suffix:N} (zero-padded to N digits), "+
+		"other characters are copied, e.g. {prefix}-{middle}-{suffix:4}")
+	fs.BoolVar(&opts.e164, "e164", false, "write numbers in E.164 international format, e.g. +8613705370000 (all 11 digits are kept)")
+	fs.BoolVar(&opts.noPrefix, "no-prefix", false, "write only middle code and suffix; the lines repeat across prefixes, "+
+		"so each combination is written once and the collapsed duplicates are reported (-dedup-mode bloom for large sets)")
+	fs.BoolVar(&opts.sepFuzz, "separator-fuzz", false, "cycle each number through separator styles such as 137-0537-0000, 137 0537 0000 and (137)05370000; "+
+		"meant for testing phone number parsers, not for realistic dictionaries")
+	fs.StringVar(&opts.logFile, "log", "", "append progress, warnings and the final summary with timestamps to a log `file` (numbers are not logged)")
+	fs.StringVar(&opts.report, "report", "", "after generation write a CSV `file` with the number of generated numbers per prefix (prefix,operator,count) and a total row")
+	fs.BoolVar(&opts.stats, "stats", false, "after generation print the count per operator and prefix, the distribution of the first suffix digit "+
+		"and the smallest and largest number (single goroutine, -workers is ignored)")
+	fs.StringVar(&opts.statsJSON, "stats-json", "", "also write the -stats report as JSON to `file` (implies -stats)")
+	fs.StringVar(&opts.checksum, "checksum", "", "only write numbers passing a `check`: luhn, or mod:N (digit sum divisible by N)")
+	fs.StringVar(&opts.compress, "compress", "none", "compress the output files: gzip (adds .gz), zstd (adds .zst) or none")
+	fs.IntVar(&opts.workers, "workers", 1, "number of goroutines formatting numbers in parallel (single txt output only; ignored with -sample, -interleave, -separator-fuzz, -index, -grouped, -no-prefix, -stats)")
+	fs.IntVar(&opts.batchSize, "batch-size", 4096, "with -workers, numbers formatted per batch handed to the writer")
+	fs.BoolVar(&opts.autoTune, "auto-tune", false, "before generating, try several -workers and -batch-size settings for about 2 seconds "+
+		"against the output directory and use the fastest")
+	fs.StringVar(&opts.maxMemory, "max-memory", "", "with -workers, cap the formatted batches waiting for the writer at `size` (e.g. 64MB, 512KB); "+
+		"workers wait when the writer lags (default: 2 batches per worker)")
+	fs.IntVar(&opts.outputBase, "output-base", 10, "write each number in `base` 2-36, zero-padded to a fixed width (e.g. 16 for hex); non-decimal output is for specialized pipelines")
+	fs.DurationVar(&opts.timeout, "timeout", 0, "stop generating after `duration` (e.g. 30s), keep the numbers written so far and exit with code 4 (default: no timeout)")
+	fs.Float64Var(&opts.stallRate, "stall-rate", 0, "abort with an error when fewer than `n` numbers per second are written over a whole -stall-window, e.g. on a failing disk (default: off)")
+	fs.DurationVar(&opts.stallWindow, "stall-window", 30*time.Second, "measuring window for -stall-rate")
+	fs.IntVar(&opts.writeRetries, "write-retries", 3, "retry a failed write up to `n` times with backoff when the error is transient (timeouts, EIO, e.g. on network filesystems); errors such as a full disk fail at once")
+	fs.BoolVar(&opts.header, "header", false, "write a '# Generated ...' comment as the first line of the output")
+	fs.BoolVar(&opts.grouped, "grouped", false, "write a '### 0537 (Jining) ###' line before the numbers of each middle code (txt only; "+
+		"region names come from -middle-csv, otherwise from the -province table)")
+	fs.BoolVar(&opts.noTrailingNL, "no-trailing-newline", false, "do not write a newline after the last number")
+	fs.BoolVar(&opts.reverseSuffix, "reverse-suffix", false, "count suffixes down (e.g. 9999 to 0000) within each prefix and middle code")
+	fs.BoolVar(&opts.sorted, "sorted", false, "write numbers in ascending numeric order across all prefixes (no extra cost, only the prefix and middle code order changes)")
+	fs.BoolVar(&opts.interleave, "interleave", false, "round-robin across operators so early lines cover every operator (changes order only)")
+	fs.StringVar(&opts.blockFile, "block-file", "", "only generate numbers inside the allocation blocks of a JSON `file`: "+
+		`{"blocks":[{"prefix":"137","middle":"0537","suffixStart":0,"suffixEnd":4999}]}, middle may be omitted to cover all middle codes`)
+	fs.StringVar(&opts.blocklist, "blocklist-regex", "", "drop numbers matching any of the comma-separated regular `patterns`, e.g. ^1[0-9]{2}0000 "+
+		"(matched against the number as written, after -template or -e164)")
+	fs.StringVar(&opts.dedupAgainst, "dedup-against", "", "skip numbers already present in an existing dictionary `file`")
+	fs.StringVar(&opts.seedFile, "seed-file", "", "write the known numbers of a `file` (one per line) first and skip them in the generated numbers")
+	fs.StringVar(&opts.deltaAgainst, "delta-against", "", "write only the numbers that are not in an older dictionary `file` to -delta-out, "+
+		"e.g. after adding a middle code, instead of the whole dictionary")
+	fs.StringVar(&opts.deltaOut, "delta-out", "", "output `file` for the new numbers of -delta-against, replaces -out")
+	fs.StringVar(&opts.complement, "complement", "", "only generate numbers absent from a CSV `file` of assigned numbers, e.g. from the carrier, "+
+		"to find the unassigned gaps in the configured space")
+	fs.StringVar(&opts.dedupMode, "dedup-mode", "exact", "`mode` for -dedup-against, -delta-against, -complement, -no-prefix and -diff: exact (in-memory set, memory grows with the file) "+
+		"or bloom (bloom filter, small memory but about -dedup-fp-rate of new numbers or combinations are wrongly skipped)")
+	fs.Float64Var(&opts.dedupFPRate, "dedup-fp-rate", 0.001, "false-positive `rate` of the bloom filter for -dedup-mode=bloom")
+	fs.BoolVar(&opts.shuffle, "shuffle", false, "write numbers in a random order (all numbers are still written exactly once)")
+	fs.BoolVar(&opts.shuffleSuffix, "shuffle-suffix", false, "write the suffixes of each prefix and middle code in a random order (all are still written once), "+
+		"cheaper than -shuffle; -seed/-daily-seed then seed it instead of implying -shuffle")
+	fs.Int64Var(&opts.seed, "seed", 0, "random `seed` for -shuffle, implies -shuffle (default: random, printed so the run can be reproduced)")
+	fs.BoolVar(&opts.dailySeed, "daily-seed", false, "shuffle with a seed derived from today's date (YYYYMMDD), so the order is stable for a day")
+	fs.Int64Var(&opts.limit, "limit", 0, "stop after writing `n` numbers (default: no limit)")
+	fs.IntVar(&opts.prefixSample, "prefix-sample", 0, "use only `k` randomly chosen prefixes, split across operators in proportion to their prefix counts (uses -seed/-daily-seed when given)")
+	fs.Int64Var(&opts.sample, "sample", 0, "write only `n` distinct numbers drawn at random (uses -seed/-daily-seed when given)")
+	fs.StringVar(&opts.weights, "weights", "", "with -sample, operator `weights` such as mobile:55,unicom:25,telecom:20; they are normalized, "+
+		"so each operator gets roughly its share of the n samples until its numbers run out (default: uniform)")
+}
+
+// 不带子命令时的参数，保持旧版本的用法
+func registerLegacyFlags(fs *flag.FlagSet, opts *options) {
+	fs.StringVar(&opts.validateFile, "validate-file", "", "check each number in `file` against the built-in prefixes and configured middle codes")
+	fs.StringVar(&opts.matchedOut, "matched-out", "", "with -validate-file, write matched numbers to `file`")
+	fs.StringVar(&opts.sortDedup, "sort-dedup", "", "sort `file` in place and remove duplicate lines (external merge sort, works on files larger than memory)")
+	fs.IntVar(&opts.chunkLines, "chunk-lines", 1000000, "with -sort-dedup, number of lines sorted in memory per chunk")
+	registerDefaultMiddleFlag(fs, opts)
+	fs.BoolVar(&opts.lint, "lint", false, "check config.json (middle codes, prefixMiddleMap, campaigns) and exit without generating; exits non-zero if it cannot be used")
+	fs.BoolVar(&opts.analyzeMiddle, "analyze-middle", false, "print per-digit statistics of the configured middle codes, flag suspicious ones (e.g. 0000, 1234) and exit")
+	fs.BoolVar(&opts.listPrefixes, "list-prefixes", false, "print the built-in prefixes grouped by operator and exit")
+	fs.BoolVar(&opts.selfTest, "selftest", false, "generate 10 numbers to a temp file, read them back and check them, print PASS or FAIL and exit (0 on success); "+
+		"for checking a packaged binary")
+	fs.BoolVar(&opts.configSchema, "config-schema", false, "print a JSON Schema of the config file fields for editor autocompletion and validation, and exit")
+	fs.StringVar(&opts.serve, "serve", "", "serve GET /generate?middle=0537&operators=mobile&limit=1000 on `addr` (e.g. :8080), streaming numbers; "+
+		"a request returns at most 10000000 numbers, larger ones need limit; there is no authentication, use on trusted networks only")
+	registerGenerateFlags(fs, opts)
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// 解析命令行：第一个参数是子命令时使用该子命令的参数集合，否则使用旧版参数。
+// 参数错误会直接打印到stderr，返回flag.ErrHelp表示用户请求了帮助
+func parseArgs(args []string) (*command, options, error) {
+	var opts options
+	var cmd *command
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	if len(args) > 0 {
+		if c, ok := findCommand(args[0]); ok {
+			cmd = &c
+			args = args[1:]
+			fs = flag.NewFlagSet(c.name, flag.ContinueOnError)
+		}
+	}
+
+	registerCommonFlags(fs, &opts)
+	if cmd != nil {
+		cmd.flags(fs, &opts)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s %s\n\n%s\n\nFlags:\n", os.Args[0], cmd.usage, cmd.summary)
+			fs.PrintDefaults()
+		}
+	} else {
+		registerLegacyFlags(fs, &opts)
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s [flags]            (interactive menu)\n", os.Args[0])
+			fmt.Fprintf(fs.Output(), "       %s <command> [flags]\n\nCommands:\n", os.Args[0])
+			for _, c := range commands {
+				fmt.Fprintf(fs.Output(), "  %-10s %s\n", c.name, c.summary)
+			}
+			fmt.Fprintf(fs.Output(), "\nRun '%s <command> -h' for command flags.\n\nFlags:\n", os.Args[0])
+			fs.PrintDefaults()
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return cmd, opts, err
+	}
+	opts.args = fs.Args()
+	opts.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		opts.set[f.Name] = true
+	})
+
+	if err := checkOptions(opts); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return cmd, opts, invalidInput(err)
+	}
+	return cmd, opts, nil
+}
+
+func checkOptions(opts options) error {
+	if _, ok := operatorLabels[opts.operatorLabel]; !ok {
+		return fmt.Errorf("invalid -operator-label %q (must be code, en or cn)", opts.operatorLabel)
+	}
+	for name, n := range map[string]int{"-prefix-len": opts.layout.prefixLen, "-middle-len": opts.layout.middleLen, "-suffix-len": opts.layout.suffixLen} {
+		if n < 1 || n > 9 {
+			return fmt.Errorf("invalid %s %d (must be between 1 and 9)", name, n)
+		}
+	}
+	maxSuffix := pow10(opts.layout.suffixLen) - 1
+	if opts.suffixStart < 0 || opts.suffixStart > maxSuffix {
+		return fmt.Errorf("invalid -suffix-start %d (must be between 0 and %d)", opts.suffixStart, maxSuffix)
+	}
+	if opts.set["suffix-end"] && (opts.suffixEnd < opts.suffixStart || opts.suffixEnd > maxSuffix) {
+		return fmt.Errorf("invalid -suffix-end %d (must be between -suffix-start %d and %d)", opts.suffixEnd, opts.suffixStart, maxSuffix)
+	}
+	if opts.suffixStep < 1 {
+		return fmt.Errorf("invalid -suffix-step %d (must be positive)", opts.suffixStep)
+	}
+	if opts.shard != "" {
+		if _, _, err := parseShard(opts.shard); err != nil {
+			return err
+		}
+	}
+	if err := checkSuffixDigitsOptions(opts); err != nil {
+		return err
+	}
+	if err := checkSuffixLenRangeOptions(opts); err != nil {
+		return err
+	}
+	if opts.set["per-pair"] {
+		start, end, step := opts.suffixStart, opts.suffixEnd, opts.suffixStep
+		if !opts.set["suffix-end"] {
+			end = maxSuffix
+		}
+		count := EstimateCount([]string{""}, []string{""}, start, end, step)
+		if opts.set["suffix-digits"] {
+			digits, _ := parseSuffixDigits(opts.suffixDigits)
+			count = int64(newDigitSuffixes(digits, opts.layout.suffixLen).Len())
+		}
+		if opts.perPair < 1 || int64(opts.perPair) > count {
+			return fmt.Errorf("invalid -per-pair %d (must be between 1 and the %d suffixes in range)", opts.perPair, count)
+		}
+	}
+	if opts.set["workers"] && opts.workers < 1 {
+		return fmt.Errorf("invalid -workers %d (must be positive)", opts.workers)
+	}
+	if opts.set["batch-size"] && opts.batchSize < 1 {
+		return fmt.Errorf("invalid -batch-size %d (must be positive)", opts.batchSize)
+	}
+	if opts.mergePrefixes && opts.prefixFile == "" {
+		return fmt.Errorf("-merge-prefixes requires -prefix-file")
+	}
+	if opts.maxMemory != "" {
+		if _, err := parseByteSize(opts.maxMemory); err != nil {
+			return fmt.Errorf("invalid -max-memory: %v", err)
+		}
+	}
+	if opts.prefixSample < 0 {
+		return fmt.Errorf("invalid -prefix-sample %d (must be positive)", opts.prefixSample)
+	}
+	if opts.limit < 0 {
+		return fmt.Errorf("invalid -limit %d (must be positive)", opts.limit)
+	}
+	if opts.sample < 0 {
+		return fmt.Errorf("invalid -sample %d (must be positive)", opts.sample)
+	}
+	if opts.weights != "" {
+		if opts.sample == 0 {
+			return fmt.Errorf("-weights requires -sample")
+		}
+		if _, err := parseWeights(opts.weights); err != nil {
+			return err
+		}
+	}
+	if opts.format != "" {
+		formats, err := parseFormats(opts.format)
+		if err != nil {
+			return err
+		}
+		if err := checkMaskOptions(formats, opts); err != nil {
+			return err
+		}
+		if err := checkRangesOptions(formats, opts); err != nil {
+			return err
+		}
+		if err := checkBinaryOptions(formats, opts); err != nil {
+			return err
+		}
+		if err := checkGroupedOptions(formats, opts); err != nil {
+			return err
+		}
+		if err := checkNoPrefixOptions(formats, opts); err != nil {
+			return err
+		}
+		if err := checkE164Options(formats, opts); err != nil {
+			return err
+		}
+	}
+	if strings.ContainsAny(opts.indexDelim, "\r\n") {
+		return fmt.Errorf("invalid -index-delim %q (must not contain a line break)", opts.indexDelim)
+	}
+	if err := checkOutParallelOptions(opts); err != nil {
+		return err
+	}
+	if err := checkZipOptions(opts); err != nil {
+		return err
+	}
+	if err := checkAppendOptions(opts); err != nil {
+		return err
+	}
+	if err := checkAutoTuneOptions(opts); err != nil {
+		return err
+	}
+	if err := checkEmitOptions(opts); err != nil {
+		return err
+	}
+	if err := checkDeltaOptions(opts); err != nil {
+		return err
+	}
+	if opts.template != "" {
+		if _, err := ParseTemplate(opts.template); err != nil {
+			return err
+		}
+	}
+	if opts.sepFuzz && opts.set["template"] {
+		return fmt.Errorf("-separator-fuzz and -template cannot be used together")
+	}
+	if opts.compress != "" {
+		if err := checkCompress(opts.compress); err != nil {
+			return err
+		}
+	}
+	if opts.checksum != "" {
+		if _, err := parseChecksum(opts.checksum); err != nil {
+			return err
+		}
+	}
+	if opts.set["blocklist-regex"] {
+		if _, err := parseBlocklist(opts.blocklist); err != nil {
+			return err
+		}
+	}
+	if opts.dedupAgainst != "" || opts.deltaAgainst != "" || opts.complement != "" || opts.noPrefix || opts.diff != "" {
+		if opts.dedupMode != "exact" && opts.dedupMode != "bloom" {
+			return fmt.Errorf("invalid -dedup-mode %q (must be exact or bloom)", opts.dedupMode)
+		}
+		if opts.dedupFPRate <= 0 || opts.dedupFPRate >= 1 {
+			return fmt.Errorf("invalid -dedup-fp-rate %v (must be between 0 and 1)", opts.dedupFPRate)
+		}
+	}
+	if opts.timeout < 0 {
+		return fmt.Errorf("invalid -timeout %v (must not be negative)", opts.timeout)
+	}
+	if opts.writeRetries < 0 {
+		return fmt.Errorf("invalid -write-retries %d (must not be negative)", opts.writeRetries)
+	}
+	if opts.stallRate < 0 {
+		return fmt.Errorf("invalid -stall-rate %v (must not be negative)", opts.stallRate)
+	}
+	if opts.stallRate > 0 && opts.stallWindow <= 0 {
+		return fmt.Errorf("invalid -stall-window %v (must be positive)", opts.stallWindow)
+	}
+	if opts.set["output-base"] && (opts.outputBase < 2 || opts.outputBase > 36) {
+		return fmt.Errorf("invalid -output-base %d (must be between 2 and 36)", opts.outputBase)
+	}
+	if opts.set["output-base"] && opts.outputBase != 10 && (opts.sepFuzz || opts.set["template"]) {
+		return fmt.Errorf("-output-base cannot be combined with -template or -separator-fuzz (only plain numbers can be converted)")
+	}
+	if opts.seedFile != "" && (opts.sepFuzz || opts.set["template"]) {
+		return fmt.Errorf("-seed-file cannot be combined with -template or -separator-fuzz (seed numbers are plain digits)")
+	}
+	if opts.sorted && (opts.interleave || opts.shuffleSuffix || opts.reverseSuffix || opts.sample > 0 || wantsShuffle(opts) || opts.seedFile != "") {
+		return fmt.Errorf("-sorted cannot be combined with -interleave, -shuffle-suffix, -reverse-suffix, -sample, -seed-file or -shuffle/-seed/-daily-seed")
+	}
+	if opts.dailySeed && opts.set["seed"] {
+		return fmt.Errorf("-daily-seed and -seed cannot be used together")
+	}
+	return nil
+}
+
+// 确定打乱顺序和抽样使用的种子：-seed > -daily-seed > 随机
+func randomSeed(opts options) int64 {
+	switch {
+	case opts.set["seed"]:
+		return opts.seed
+	case opts.dailySeed:
+		seed, _ := strconv.ParseInt(time.Now().Format("20060102"), 10, 64)
+		return seed
+	}
+	return time.Now().UnixNano()
+}
+
+// 解析-shard=R/M
+func parseShard(s string) (int, int, error) {
+	rs, ms, ok := strings.Cut(s, "/")
+	r, errR := strconv.Atoi(strings.TrimSpace(rs))
+	m, errM := strconv.Atoi(strings.TrimSpace(ms))
+	if !ok || errR != nil || errM != nil || m < 1 || r < 0 || r >= m {
+		return 0, 0, fmt.Errorf("invalid -shard %q (must be R/M with 0 <= R < M, e.g. 0/4)", s)
+	}
+	return r, m, nil
+}
+
+// 解析64MB、512KB、1GB或纯字节数形式的大小，单位按1024进位，不区分大小写
+func parseByteSize(s string) (int64, error) {
+	units := []struct {
+		suffix string
+		scale  int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	scale := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper, scale = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix)), unit.scale
+			break
+		}
+	}
+	n, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || n < 1 || n > math.MaxInt64/scale {
+		return 0, fmt.Errorf("%q is not a positive size such as 64MB, 512KB or 1048576", s)
+	}
+	return n * scale, nil
+}
+
+// 尾号范围：-suffix-start、-suffix-end（未设置时为该位数的最大值）和-suffix-step
+func suffixBounds(opts options) (int, int, int) {
+	end := opts.suffixEnd
+	if !opts.set["suffix-end"] {
+		end = pow10(layout.suffixLen) - 1
+	}
+	return opts.suffixStart, end, opts.suffixStep
+}
+
+// 是否需要打乱输出顺序，指定种子即表示需要打乱
+func wantsShuffle(opts options) bool {
+	return opts.shuffle || (opts.dailySeed || opts.set["seed"]) && !opts.shuffleSuffix
+}
+
+// 解析 mobile:55,unicom:25,telecom:20 形式的运营商权重
+func parseWeights(input string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	positive := false
+	for _, item := range strings.Split(input, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %q (expected operator:weight)", item)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := operatorLabels["en"][name]; !known {
+			return nil, fmt.Errorf("unknown operator %q in -weights (must be mobile, unicom or telecom)", name)
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q for %s (must be a non-negative number)", value, name)
+		}
+		weights[name] = w
+		positive = positive || w > 0
+	}
+	if !positive {
+		return nil, fmt.Errorf("-weights must give at least one operator a positive weight")
+	}
+	return weights, nil
+}
+
+// 把运营商权重平均分配到该运营商的每个号段，未列出的运营商权重为0
+func prefixWeights(segments Segments, operatorWeights map[string]float64) map[string]float64 {
+	weights := make(map[string]float64)
+	for operator, w := range operatorWeights {
+		prefixes := segments.Prefixes(operator)
+		for _, prefix := range prefixes {
+			weights[prefix] = w / float64(len(prefixes))
+		}
+	}
+	return weights
+}
+
+// 非交互模式下的中间码来源：-middle优先，其次-province、-middle-csv，否则读取config.json；
+// operatorSuffixRanges总是取自配置文件
+func resolveMiddleCodes(opts options) (Config, error) {
+	if opts.middle == "" && opts.province != "" {
+		middleCodes, regions, err := parseProvinces(opts.province)
+		if err != nil {
+			return Config{}, invalidInput(err)
+		}
+		fmt.Fprintf(infoOut, "Resolved %d %d-digit middle codes from -province %s\n", len(middleCodes), layout.middleLen, opts.province)
+		printRegionSummary(middleCodes, regions)
+		return withConfigSuffixRanges(Config{MiddleCodes: middleCodes, Regions: regions})
+	}
+	if opts.middle == "" && opts.middleCSV != "" {
+		middleCodes, regions, err := loadMiddleCSV(opts.middleCSV)
+		if err != nil {
+			return Config{}, invalidInput(err)
+		}
+		fmt.Fprintf(infoOut, "Read %d %d-digit middle codes from %s\n", len(middleCodes), layout.middleLen, opts.middleCSV)
+		printRegionSummary(middleCodes, regions)
+		return withConfigSuffixRanges(Config{MiddleCodes: middleCodes, Regions: regions})
+	}
+	if opts.middle == "" {
+		middleCodes, err := middleCodesFromEnv()
+		if err != nil {
+			return Config{}, err
+		}
+		if middleCodes != nil {
+			return withConfigSuffixRanges(Config{MiddleCodes: middleCodes})
+		}
+		return loadMiddleCodesFromConfig(opts.defaultMiddle)
+	}
+	middleCodes, err := parseMiddleCodes(opts.middle)
+	if err != nil {
+		return Config{}, invalidInput(err)
+	}
+	fmt.Fprintf(infoOut, "Using %d %d-digit middle codes from -middle: %v\n", len(middleCodes), layout.middleLen, middleCodes)
+	return withConfigSuffixRanges(Config{MiddleCodes: middleCodes})
+}
+
+func runGenerate(segments Segments, opts options) error {
+	if opts.printConfig || opts.preview || opts.diff != "" || opts.emitRate != "" || opts.densityReport != "" {
+		config, err := resolveMiddleCodes(opts)
+		if err != nil {
+			return err
+		}
+		if opts.preview {
+			return printPreview(segments, config, opts)
+		}
+		if opts.diff != "" {
+			return printDiff(segments, config, opts)
+		}
+		if opts.emitRate != "" {
+			return emitNumbers(segments, config, opts)
+		}
+		if opts.densityReport != "" {
+			return writeDensityReport(segments, config, opts)
+		}
+		return printEffectiveConfig(segments, config, opts)
+	}
+	for _, path := range outputPaths(opts) {
+		if err := checkOutputDir(path); err != nil {
+			return err
+		}
+	}
+	config, err := resolveMiddleCodes(opts)
+	if err != nil {
+		return err
+	}
+	if len(config.Campaigns) > 0 && opts.outParallel != "" {
+		return invalidInput(fmt.Errorf("-out-parallel cannot be used with campaigns in %s", configFile))
+	}
+	if len(config.Campaigns) > 0 && opts.zip != "" {
+		return invalidInput(fmt.Errorf("-zip cannot be used with campaigns in %s", configFile))
+	}
+	if len(config.Campaigns) > 0 {
+		err = generateCampaigns(segments, config, opts)
+	} else {
+		_, err = generatePhoneNumbers(segments, config, opts)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(infoOut, "\n✅ Phone numbers have been successfully exported")
+	return nil
+}
+
+func runCount(segments Segments, opts options) error {
+	config, err := resolveMiddleCodes(opts)
+	if err != nil {
+		return err
+	}
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	printGenerationPlan(segments.All(), config.MiddleCodes, config.prefixMiddles(segments), nil, opts, []string{"txt"})
+	reportOperatorSuffixRanges(config.OperatorSuffixRanges, segments)
+	return nil
+}
+
+func runValidate(segments Segments, opts options) error {
+	if len(opts.args) != 1 {
+		return invalidInput(fmt.Errorf("validate needs exactly one numbers file, e.g. validate numbers.txt"))
+	}
+	config, err := resolveMiddleCodes(opts)
+	if err != nil {
+		return err
+	}
+	return validateNumbersFile(opts.args[0], segments, config, opts.matchedOut)
+}
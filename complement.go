@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 读取运营商提供的已分配号码CSV，用于-complement只生成其中没有的号码（号码空间中的空号）。
+// 每行取第一个去掉空格、连字符和+86后是完整号码的字段，没有这样字段的行（如标题行）被跳过
+func loadAssignedNumbers(path, mode string, fpRate float64) (set numberSet, count, skipped int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	switch mode {
+	case "exact":
+		set = make(exactSet)
+	case "bloom":
+		info, err := file.Stat()
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to check %s status: %v", path, err)
+		}
+		// CSV每行至少有一个号码和换行符，按此估算的行数偏多，误判率只会更低
+		set = newBloomFilter(uint64(info.Size())/uint64(layout.totalLen()+1)+1, fpRate)
+	default:
+		return nil, 0, 0, fmt.Errorf("unknown dedup mode %q (must be exact or bloom)", mode)
+	}
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		number, ok := "", false
+		for _, field := range record {
+			if number, ok = assignedNumber(field); ok {
+				break
+			}
+		}
+		if !ok {
+			skipped++
+			continue
+		}
+		set.Add(number)
+		count++
+	}
+	return set, count, skipped, nil
+}
+
+// 把CSV字段规范为号码：去掉空格和连字符，以及E.164的+86或86前缀
+func assignedNumber(field string) (string, bool) {
+	number := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(field))
+	number = strings.TrimPrefix(number, "+")
+	if len(number) == len(e164CountryCode)+layout.totalLen() {
+		number = strings.TrimPrefix(number, e164CountryCode)
+	}
+	if len(number) != layout.totalLen() {
+		return "", false
+	}
+	for _, ch := range number {
+		if ch < '0' || ch > '9' {
+			return "", false
+		}
+	}
+	return number, true
+}
+
+// 报告空号数量与整个号码空间的比较；-limit、-sample或其他过滤条件使部分号码没有经过检查时，比例没有意义，改为说明检查了多少
+func reportComplement(path string, unassigned, assigned int64, perPrefix map[string]int64) {
+	var space int64
+	for _, n := range perPrefix {
+		space += n
+	}
+	logf("Complement of %s: %d unassigned numbers, %d assigned, of %d in the configured space\n", path, unassigned, assigned, space)
+	if checked := unassigned + assigned; checked < space {
+		logf("⚠️ Only %d numbers of the space were checked against %s (the others were limited, sampled or filtered out)\n", checked, path)
+	} else if space > 0 {
+		logf("Unassigned share of the space: %.4f%%\n", float64(unassigned)/float64(space)*100)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAssignedNumber(t *testing.T) {
+	for field, want := range map[string]string{
+		"13705370001":       "13705370001",
+		" 137 0537 0001 ":   "13705370001",
+		"137-0537-0001":     "13705370001",
+		"+8613705370001":    "13705370001",
+		"86 137 0537 0001":  "13705370001",
+		"+86-137-0537-0001": "13705370001",
+		"number":            "",
+		"1370537000":        "",
+		"1370537000x":       "",
+	} {
+		got, ok := assignedNumber(field)
+		if got != want || ok != (want != "") {
+			t.Errorf("assignedNumber(%q) = %q, %v, want %q", field, got, ok, want)
+		}
+	}
+}
+
+// 已分配的号码（任意列、不同写法，以及空间外的号码）不出现在输出中，报告空号数与空间大小
+func TestComplementSmallAssignedSet(t *testing.T) {
+	for _, mode := range []string{"exact", "bloom"} {
+		t.Run(mode, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv(envMiddleCodes, "")
+			assigned := "id,number,owner\n1,137 0537 0001,a\n2,+86-137-0537-0002,b\n3,8613705370003,c\n4,13705370003,dup\n5,13805370000,other prefix\n"
+			if err := os.WriteFile("assigned.csv", []byte(assigned), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile("prefixes.txt", []byte("137\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			segments, err := loadPrefixFile("prefixes.txt", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := legacyOptions(t, "-complement", "assigned.csv", "-dedup-mode", mode, "-force")
+			var n int64
+			stdout := captureStdout(t, func() {
+				n, err = generatePhoneNumbers(segments, Config{MiddleCodes: []string{"0537"}}, opts)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile("phonedict.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if n != 9997 || len(lines) != 9997 {
+				t.Fatalf("got %d numbers in %d lines, want 9997", n, len(lines))
+			}
+			if lines[0] != "13705370000" || lines[1] != "13705370004" {
+				t.Fatalf("output starts with %q", lines[:2])
+			}
+			for _, want := range []string{
+				"Loaded 5 assigned numbers from assigned.csv for -complement (" + mode + " mode, 1 rows without a number skipped)",
+				"Complement of assigned.csv: 9997 unassigned numbers, 3 assigned, of 10000 in the configured space",
+			} {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("output is missing %q:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
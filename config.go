@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Config struct {
+	MiddleCodes []string `json:"middleCodes" yaml:"middleCodes" toml:"middleCodes"`
+	// 可选：号段到中间码列表的映射，列出的号段只与这些中间码组合
+	PrefixMiddleMap map[string][]string `json:"prefixMiddleMap,omitempty" yaml:"prefixMiddleMap,omitempty" toml:"prefixMiddleMap,omitempty"`
+	// 可选：middleCodes为空时使用的中间码，-default-middle优先
+	DefaultMiddleCodes []string `json:"defaultMiddleCodes,omitempty" yaml:"defaultMiddleCodes,omitempty" toml:"defaultMiddleCodes,omitempty"`
+	// 可选：命名的中间码分组，每组生成一个单独的文件，存在时代替middleCodes
+	Campaigns map[string][]string `json:"campaigns,omitempty" yaml:"campaigns,omitempty" toml:"campaigns,omitempty"`
+	// 可选：运营商到尾号范围的映射，列出的运营商的号段只生成该范围内的尾号，其余使用全局范围
+	OperatorSuffixRanges map[string]OperatorSuffixRange `json:"operatorSuffixRanges,omitempty" yaml:"operatorSuffixRanges,omitempty" toml:"operatorSuffixRanges,omitempty"`
+	// 中间码到地区名的映射，来自-middle-csv或-province，不写入配置文件
+	Regions map[string]string `json:"-" yaml:"-" toml:"-"`
+}
+
+// 配置文件路径，由resolveConfigFile根据-config和已有的文件确定
+var configFile = "config.json"
+
+// 未指定-config时默认使用config.json；它不存在时依次查找其他格式的同名文件，都不存在时仍为config.json
+func resolveConfigFile(path string, explicit bool) string {
+	if explicit {
+		return path
+	}
+	for _, candidate := range []string{"config.json", "config.yaml", "config.yml", "config.toml"} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return "config.json"
+}
+
+// 配置错误的类别，可用errors.Is判断，例如errors.Is(err, ErrConfigParse)
+var (
+	ErrConfigNotFound     = errors.New("config file not found")
+	ErrConfigIO           = errors.New("config file cannot be accessed")
+	ErrConfigParse        = errors.New("config file cannot be parsed")
+	ErrConfigNoValidCodes = errors.New("config file has no valid middle codes")
+)
+
+// ConfigError 配置文件处理失败，Kind为上面的某个ErrConfig*，Err为底层错误（可能为nil）
+type ConfigError struct {
+	Kind error
+	Path string
+	Msg  string
+	Err  error
+}
+
+func newConfigError(kind error, path string, err error, format string, args ...any) *ConfigError {
+	return &ConfigError{Kind: kind, Path: path, Msg: fmt.Sprintf(format, args...), Err: err}
+}
+
+func (e *ConfigError) Error() string {
+	if e.Err == nil {
+		return e.Msg
+	}
+	return e.Msg + ": " + e.Err.Error()
+}
+
+// 同时暴露类别和底层错误，errors.Is/errors.As对两者都有效
+func (e *ConfigError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// 从配置文件读取中间码，列表为空时回退到defaultMiddle（-default-middle）或配置中的defaultMiddleCodes，
+// 两者都为空时报错
+func loadMiddleCodesFromConfig(defaultMiddle string) (Config, error) {
+	config, err := loadConfig()
+	if err != nil {
+		return config, err
+	}
+	if len(config.Campaigns) > 0 {
+		config.MiddleCodes = config.campaignMiddleCodes()
+		fmt.Fprintf(infoOut, "%s defines %d campaigns: %s\n", configFile, len(config.Campaigns), strings.Join(config.campaignNames(), ", "))
+	}
+	if len(config.MiddleCodes) == 0 {
+		fallback := config.DefaultMiddleCodes
+		if defaultMiddle != "" {
+			codes, err := parseMiddleCodes(defaultMiddle)
+			if err != nil {
+				return config, invalidInput(fmt.Errorf("invalid -default-middle: %v", err))
+			}
+			fallback = codes
+		}
+		if len(fallback) == 0 {
+			return config, newConfigError(ErrConfigNoValidCodes, configFile, nil,
+				"middleCodes in %s is empty and no fallback is set (use -default-middle or defaultMiddleCodes)", configFile)
+		}
+		logf("Warning: middleCodes in %s is empty, using fallback middle codes %v\n", configFile, fallback)
+		config.MiddleCodes = fallback
+	}
+	fmt.Fprintf(infoOut, "Successfully read %d %d-digit middle codes from config file: %v\n", len(config.MiddleCodes), layout.middleLen, config.MiddleCodes)
+	if len(config.PrefixMiddleMap) > 0 {
+		fmt.Fprintf(infoOut, "prefixMiddleMap restricts middle codes for %d prefixes\n", len(config.PrefixMiddleMap))
+	}
+	if len(config.OperatorSuffixRanges) > 0 {
+		fmt.Fprintf(infoOut, "operatorSuffixRanges sets the suffix range for %d operators\n", len(config.OperatorSuffixRanges))
+	}
+	return config, nil
+}
+
+// 中间码来自命令行或环境变量时，配置文件中的operatorSuffixRanges仍然生效；
+// 配置文件不存在时不自动创建，无法读取时打印警告后忽略
+func withConfigSuffixRanges(config Config) (Config, error) {
+	if _, err := os.Stat(configFile); err != nil {
+		return config, nil
+	}
+	fileConfig, err := readConfigFile(configFile)
+	if err != nil {
+		logf("Warning: %v, operatorSuffixRanges in it ignored\n", err)
+		return config, nil
+	}
+	if err := checkOperatorSuffixRanges(fileConfig.OperatorSuffixRanges, configFile); err != nil {
+		return config, err
+	}
+	config.OperatorSuffixRanges = fileConfig.OperatorSuffixRanges
+	if len(config.OperatorSuffixRanges) > 0 {
+		fmt.Fprintf(infoOut, "operatorSuffixRanges in %s sets the suffix range for %d operators\n", configFile, len(config.OperatorSuffixRanges))
+	}
+	return config, nil
+}
+
+// 校验prefixMiddleMap：号段必须是已知号段，中间码必须在middleCodes中，
+// 无效的组合打印警告后跳过；返回号段到可用中间码的映射，未配置时返回nil
+func (config Config) prefixMiddles(segments Segments) map[string][]string {
+	if len(config.PrefixMiddleMap) == 0 {
+		return nil
+	}
+	known := segments.Operators()
+	configured := make(map[string]bool)
+	for _, code := range config.MiddleCodes {
+		configured[code] = true
+	}
+
+	allowed := make(map[string][]string)
+	for _, prefix := range segments.All() {
+		codes, ok := config.PrefixMiddleMap[prefix]
+		if !ok {
+			continue
+		}
+		seen := make(map[string]bool)
+		valid := []string{}
+		for _, code := range codes {
+			if seen[code] {
+				continue
+			}
+			seen[code] = true
+			if !configured[code] {
+				logf("Warning: prefixMiddleMap %s -> %s skipped (middle code not in middleCodes)\n", prefix, code)
+				continue
+			}
+			valid = append(valid, code)
+		}
+		if len(valid) == 0 {
+			logf("Warning: prefixMiddleMap leaves no middle codes for prefix %s, it will generate no numbers\n", prefix)
+		}
+		allowed[prefix] = valid
+	}
+	var unknown []string
+	for prefix := range config.PrefixMiddleMap {
+		if known[prefix] == "" {
+			unknown = append(unknown, prefix)
+		}
+	}
+	sort.Strings(unknown)
+	for _, prefix := range unknown {
+		logf("Warning: prefixMiddleMap entry for unknown prefix %s skipped\n", prefix)
+	}
+	return allowed
+}
+
+// 配置文件不存在时是否自动创建，-no-auto-create关闭
+var autoCreateConfig = true
+
+// 有无效中间码时报错而不是跳过，由-strict-middle设置
+var strictMiddle bool
+
+// 自动创建的配置文件内容
+var sampleConfig = Config{
+	MiddleCodes: []string{"0537", "0100", "0210", "0755"},
+}
+
+// 单行的配置文件示例，用于说明期望的格式
+func configExample(format string) string {
+	data, _ := encodeConfig(sampleConfig, format)
+	if format == "json" {
+		var compact bytes.Buffer
+		if json.Compact(&compact, data) == nil {
+			return compact.String()
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func loadConfig() (Config, error) {
+	var config Config
+	configPath := configFile
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		defaultConfig := sampleConfig
+		if !autoCreateConfig {
+			return config, newConfigError(ErrConfigNotFound, configPath, nil,
+				"%s not found and -no-auto-create is set; create it with %d-digit middle codes, e.g. %s", configPath, layout.middleLen, configExample(configFormat(configPath)))
+		}
+		fmt.Fprintf(infoOut, "%s not found, creating automatically...\n", configPath)
+		// 按文件扩展名选择格式
+		data, err := encodeConfig(defaultConfig, configFormat(configPath))
+		if err != nil {
+			return config, newConfigError(ErrConfigIO, configPath, err, "failed to generate default config")
+		}
+		if err := os.WriteFile(configPath, data, 0644); err != nil {
+			return config, newConfigError(ErrConfigIO, configPath, err, "failed to create %s", configPath)
+		}
+		fmt.Fprintf(infoOut, "✅ %s created successfully, sample middle codes: %v\n", configPath, defaultConfig.MiddleCodes)
+		fmt.Fprintf(infoOut, "Note: You can edit this file directly to modify the middleCodes list (must be %d-digit numbers)\n", layout.middleLen)
+		return defaultConfig, nil
+	} else if err != nil {
+		return config, newConfigError(ErrConfigIO, configPath, err, "failed to check %s status", configPath)
+	}
+
+	config, err := readConfigFile(configPath)
+	if err != nil {
+		return config, err
+	}
+	if invalid := config.invalidMiddleCodes(); strictMiddle && len(invalid) > 0 {
+		return config, newConfigError(ErrConfigParse, configPath, nil,
+			"%d invalid middle codes in %s (-strict-middle): %s", len(invalid), configPath, strings.Join(invalid, "; "))
+	}
+
+	config.MiddleCodes = expandConfigCodes(config.MiddleCodes, func(err error) {
+		logf("Warning: %v in middleCodes of %s, skipped\n", err, configPath)
+	})
+	validDefaults := expandConfigCodes(config.DefaultMiddleCodes, func(err error) {
+		logf("Warning: %v in defaultMiddleCodes of %s, skipped\n", err, configPath)
+	})
+	config.DefaultMiddleCodes = validDefaults
+	config.Campaigns = validCampaigns(config.Campaigns, configPath)
+	if err := checkOperatorSuffixRanges(config.OperatorSuffixRanges, configPath); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// 读取并解析配置文件，不做任何校验
+func readConfigFile(configPath string) (Config, error) {
+	var config Config
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, newConfigError(ErrConfigNotFound, configPath, err, "%s not found", configPath)
+		}
+		return config, newConfigError(ErrConfigIO, configPath, err, "failed to read %s", configPath)
+	}
+	data, err = stripUTF8BOM(data)
+	if err != nil {
+		return config, newConfigError(ErrConfigParse, configPath, nil, "%s must be UTF-8 encoded: %v", configPath, err)
+	}
+
+	config, err = decodeConfig(data, configFormat(configPath))
+	if err != nil {
+		return config, newConfigError(ErrConfigParse, configPath, err, "failed to parse %s as %s (check commas, quotes and indentation)", configPath, strings.ToUpper(configFormat(configPath)))
+	}
+	return config, nil
+}
+
+// 去掉UTF-8 BOM（Windows记事本常见），UTF-16文件无法按JSON解析，直接报错
+func stripUTF8BOM(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return data[3:], nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return nil, fmt.Errorf("found a UTF-16 byte order mark, save the file as UTF-8")
+	}
+	return data, nil
+}
+
+// 逐项展开配置中的中间码（支持通配符和范围）并去重，无法解析的项交给warn后跳过；结果不为nil
+func expandConfigCodes(codes []string, warn func(err error)) []string {
+	valid := []string{}
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		expanded, err := expandMiddleToken(code)
+		if err != nil {
+			warn(err)
+			continue
+		}
+		for _, c := range expanded {
+			if !seen[c] {
+				seen[c] = true
+				valid = append(valid, c)
+			}
+		}
+	}
+	return valid
+}
+
+// 配置中所有无法解析的中间码，按字段列出，供-strict-middle一次报告全部问题
+func (config Config) invalidMiddleCodes() []string {
+	var invalid []string
+	check := func(field string, codes []string) {
+		for _, code := range codes {
+			if _, err := expandMiddleToken(code); err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %v", field, err))
+			}
+		}
+	}
+	check("middleCodes", config.MiddleCodes)
+	check("defaultMiddleCodes", config.DefaultMiddleCodes)
+	for _, name := range config.campaignNames() {
+		check("campaigns."+name, config.Campaigns[name])
+	}
+	return invalid
+}
+
+// 分组名用作文件名的一部分，只允许字母、数字、下划线和短横线
+var campaignNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// 校验campaigns：跳过名称不合法的分组和无效的中间码，没有可用中间码的分组整体跳过
+func validCampaigns(campaigns map[string][]string, configPath string) map[string][]string {
+	if len(campaigns) == 0 {
+		return nil
+	}
+	valid := make(map[string][]string)
+	for name, codes := range campaigns {
+		if !campaignNameRegex.MatchString(name) {
+			logf("Warning: campaign %q in %s skipped (names may only contain letters, digits, _ and -)\n", name, configPath)
+			continue
+		}
+		validCodes := expandConfigCodes(codes, func(err error) {
+			logf("Warning: %v in campaign %s of %s, skipped\n", err, name, configPath)
+		})
+		if len(validCodes) == 0 {
+			logf("Warning: campaign %s in %s has no valid middle codes, skipped\n", name, configPath)
+			continue
+		}
+		valid[name] = validCodes
+	}
+	return valid
+}
+
+// 按名称排序的分组名，决定生成顺序
+func (config Config) campaignNames() []string {
+	names := make([]string, 0, len(config.Campaigns))
+	for name := range config.Campaigns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 所有分组的中间码（去重，按分组名顺序），用于估算和校验
+func (config Config) campaignMiddleCodes() []string {
+	seen := make(map[string]bool)
+	var codes []string
+	for _, name := range config.campaignNames() {
+		for _, code := range config.Campaigns[name] {
+			if !seen[code] {
+				seen[code] = true
+				codes = append(codes, code)
+			}
+		}
+	}
+	return codes
+}
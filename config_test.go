@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigFileBOM(t *testing.T) {
+	config, err := readConfigFile("testdata/config_utf8_bom.json")
+	if err != nil {
+		t.Fatalf("UTF-8 BOM config: %v", err)
+	}
+	if got := strings.Join(config.MiddleCodes, ","); got != "0537,0100" {
+		t.Fatalf("UTF-8 BOM config gave middle codes %s", got)
+	}
+
+	_, err = readConfigFile("testdata/config_utf16le_bom.json")
+	if err == nil {
+		t.Fatal("UTF-16 config was accepted")
+	}
+	if !errors.Is(err, ErrConfigParse) || !strings.Contains(err.Error(), "must be UTF-8 encoded") {
+		t.Fatalf("UTF-16 config error is not actionable: %v", err)
+	}
+}
+
+// 三种格式解码出相同的配置；YAML中不加引号的中间码保持前导零
+func TestDecodeConfigFormats(t *testing.T) {
+	want := Config{
+		MiddleCodes:          []string{"0537", "0100"},
+		PrefixMiddleMap:      map[string][]string{"134": {"0100"}},
+		OperatorSuffixRanges: map[string]OperatorSuffixRange{"telecom": {Start: 0, End: 4999}},
+	}
+	for format, data := range map[string]string{
+		"json": `{"middleCodes": ["0537", "0100"], "prefixMiddleMap": {"134": ["0100"]}, "operatorSuffixRanges": {"telecom": {"start": 0, "end": 4999}}}`,
+		"yaml": "middleCodes:\n  - 0537\n  - 0100\nprefixMiddleMap:\n  134: [0100]\noperatorSuffixRanges:\n  telecom: {start: 0, end: 4999}\n",
+		"toml": "middleCodes = [\"0537\", \"0100\"]\n[prefixMiddleMap]\n134 = [\"0100\"]\n[operatorSuffixRanges.telecom]\nstart = 0\nend = 4999\n",
+	} {
+		config, err := decodeConfig([]byte(data), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(config, want) {
+			t.Errorf("%s decoded to %+v, want %+v", format, config, want)
+		}
+	}
+	// TOML的数字不能代替字符串
+	if _, err := decodeConfig([]byte("middleCodes = [537]\n"), "toml"); err == nil {
+		t.Error("a TOML integer was accepted as a middle code")
+	}
+}
+
+// 自动创建的配置能按同一格式读回
+func TestEncodeConfigRoundTrip(t *testing.T) {
+	config := Config{
+		MiddleCodes:          []string{"0537", "0100", "0908"},
+		DefaultMiddleCodes:   []string{"0755"},
+		Campaigns:            map[string][]string{"north": {"0100", "0210"}},
+		OperatorSuffixRanges: map[string]OperatorSuffixRange{"mobile": {Start: 100, End: 200}},
+		Regions:              map[string]string{"0537": "Jining"},
+	}
+	want := config
+	want.Regions = nil
+	for _, format := range []string{"json", "yaml", "toml"} {
+		data, err := encodeConfig(config, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if strings.Contains(string(data), "Jining") {
+			t.Errorf("%s output contains the regions:\n%s", format, data)
+		}
+		got, err := decodeConfig(data, format)
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", format, err, data)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s round trip gave %+v\n%s", format, got, data)
+		}
+	}
+	if got, want := configExample("yaml"), `middleCodes: ["0537", "0100", "0210", "0755"]`; got != want {
+		t.Errorf("configExample(yaml) = %q, want %q", got, want)
+	}
+	if got, want := configExample("toml"), `middleCodes = ["0537", "0100", "0210", "0755"]`; got != want {
+		t.Errorf("configExample(toml) = %q, want %q", got, want)
+	}
+}
+
+// 中间码来自-middle或NG_MIDDLE_CODES时仍使用配置文件中的operatorSuffixRanges，配置文件不存在时不创建
+func TestResolveMiddleCodesKeepsSuffixRanges(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(envMiddleCodes, "")
+	if _, err := resolveMiddleCodes(legacyOptions(t, "count", "-middle", "0537")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Fatalf("-middle created %s", configFile)
+	}
+
+	if err := os.WriteFile(configFile, []byte(`{"middleCodes": ["0100"], "operatorSuffixRanges": {"mobile": {"start": 0, "end": 9}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]OperatorSuffixRange{"mobile": {Start: 0, End: 9}}
+	for name, args := range map[string][]string{
+		"-middle":   {"-middle", "0537"},
+		"-province": {"-province", "Shandong"},
+	} {
+		config, err := resolveMiddleCodes(legacyOptions(t, append([]string{"count"}, args...)...))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(config.OperatorSuffixRanges, want) {
+			t.Errorf("%s: operatorSuffixRanges %v, want %v", name, config.OperatorSuffixRanges, want)
+		}
+	}
+	t.Setenv(envMiddleCodes, "0537")
+	config, err := resolveMiddleCodes(legacyOptions(t, "count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config.OperatorSuffixRanges, want) {
+		t.Errorf("%s: operatorSuffixRanges %v, want %v", envMiddleCodes, config.OperatorSuffixRanges, want)
+	}
+
+	if err := os.WriteFile(configFile, []byte(`{"operatorSuffixRanges": {"mobile": {"start": 9, "end": 0}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveMiddleCodes(legacyOptions(t, "count", "-middle", "0537")); !errors.Is(err, ErrConfigParse) {
+		t.Fatalf("invalid operatorSuffixRanges with -middle gave %v", err)
+	}
+}
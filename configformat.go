@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件格式按扩展名判断：.yaml/.yml为YAML，.toml为TOML，其余按JSON处理
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return "json"
+}
+
+// 按格式解析配置文件，各格式共用Config的字段。YAML中不加引号的0100解码到字符串字段时保持原文，
+// 不会被当作数字；TOML的中间码必须加引号
+func decodeConfig(data []byte, format string) (Config, error) {
+	var config Config
+	var err error
+	switch format {
+	case "yaml":
+		err = yaml.Unmarshal(data, &config)
+	case "toml":
+		_, err = toml.Decode(string(data), &config)
+	default:
+		err = json.Unmarshal(data, &config)
+	}
+	return config, err
+}
+
+// 按格式输出配置，用于自动创建配置文件
+func encodeConfig(config Config, format string) ([]byte, error) {
+	var b bytes.Buffer
+	switch format {
+	case "yaml":
+		var doc yaml.Node
+		if err := doc.Encode(config); err != nil {
+			return nil, err
+		}
+		// 中间码列表写成["0537", "0100"]的行内形式，与JSON和TOML一致
+		flowSequences(&doc)
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	case "toml":
+		if err := toml.NewEncoder(&b).Encode(config); err != nil {
+			return nil, err
+		}
+	default:
+		return json.MarshalIndent(config, "", "  ")
+	}
+	return b.Bytes(), nil
+}
+
+func flowSequences(node *yaml.Node) {
+	if node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+	for _, child := range node.Content {
+		flowSequences(child)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"strings"
+)
+
+// 号码集合，用于跨次运行去重
+type numberSet interface {
+	Add(number string)
+	Contains(number string) bool
+}
+
+// 精确集合，内存占用与号码数量成正比
+type exactSet map[string]struct{}
+
+func (s exactSet) Add(number string) { s[number] = struct{}{} }
+
+func (s exactSet) Contains(number string) bool {
+	_, ok := s[number]
+	return ok
+}
+
+// 布隆过滤器：不会漏判（已存在的号码一定被识别），但有fpRate概率把新号码误判为已存在而跳过
+type bloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    uint64
+}
+
+// 按预计元素数量n和误判率fpRate计算位数组大小和哈希函数个数
+func newBloomFilter(n uint64, fpRate float64) *bloomFilter {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// 双重哈希：第i个哈希为 h1 + i*h2
+func (b *bloomFilter) hashes(number string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(number))
+	h1 := h.Sum64()
+	return h1, splitmix64(h1) | 1
+}
+
+func (b *bloomFilter) Add(number string) {
+	h1, h2 := b.hashes(number)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b *bloomFilter) Contains(number string) bool {
+	h1, h2 := b.hashes(number)
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// 读取已有字典中的号码（跳过空行和#注释行）构建去重集合，mode为exact或bloom
+func loadNumberSet(path, mode string, fpRate float64) (numberSet, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	var set numberSet
+	switch mode {
+	case "exact":
+		set = make(exactSet)
+	case "bloom":
+		info, err := file.Stat()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to check %s status: %v", path, err)
+		}
+		// 按每行号码长度加换行符估算行数
+		set = newBloomFilter(uint64(info.Size())/uint64(layout.totalLen()+1)+1, fpRate)
+	default:
+		return nil, 0, fmt.Errorf("unknown dedup mode %q (must be exact or bloom)", mode)
+	}
+
+	var count int64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		set.Add(line)
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return set, count, nil
+}
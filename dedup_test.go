@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBloomFilterNoFalseNegatives(t *testing.T) {
+	var existing bytes.Buffer
+	if _, err := Generate(&existing, []string{"137", "189"}, []string{"0537"}, WithSuffixRange(0, 9999, 3)); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "existing.txt")
+	if err := os.WriteFile(path, existing.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, count, err := loadNumberSet(path, "bloom", 0.01)
+	if err != nil {
+		t.Fatalf("loadNumberSet returned %v", err)
+	}
+	lines := strings.Fields(existing.String())
+	if count != int64(len(lines)) {
+		t.Fatalf("loaded %d numbers, want %d", count, len(lines))
+	}
+	for _, number := range lines {
+		if !set.Contains(number) {
+			t.Fatalf("bloom filter lost %s", number)
+		}
+	}
+
+	// 重新生成全部号码时，已有的号码必须全部被跳过
+	var out bytes.Buffer
+	if _, err := Generate(&out, []string{"137", "189"}, []string{"0537"}, WithFilter(excludeSet(set))); err != nil {
+		t.Fatal(err)
+	}
+	written := make(map[string]bool)
+	for _, number := range strings.Fields(out.String()) {
+		written[number] = true
+	}
+	for _, number := range lines {
+		if written[number] {
+			t.Fatalf("duplicate %s was written", number)
+		}
+	}
+	// 误判率为1%时，新号码被误跳过的比例应远低于10%
+	fresh := 20000 - len(lines)
+	if skipped := fresh - len(written); skipped > fresh/10 {
+		t.Fatalf("%d of %d new numbers were skipped", skipped, fresh)
+	}
+}
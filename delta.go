@@ -0,0 +1,22 @@
+package main
+
+import "fmt"
+
+// -delta-against只把旧字典中没有的号码写入-delta-out，与-diff对应的写出版本，
+// 增加中间码后只需把新增部分交给下游。-delta-out代替-out，旧文件同样按-dedup-mode读入
+var deltaIncompatibleFlags = []string{"out", "dedup-against", "out-parallel", "zip"}
+
+func checkDeltaOptions(opts options) error {
+	if opts.deltaAgainst == "" && opts.deltaOut == "" {
+		return nil
+	}
+	if opts.deltaAgainst == "" || opts.deltaOut == "" {
+		return fmt.Errorf("-delta-against and -delta-out must be used together")
+	}
+	for _, name := range deltaIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-delta-against cannot be used with -%s", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// 不在省份表中、也没有地区名的中间码归入此行
+const unknownProvince = "(unknown)"
+
+// -density-report：不生成号码，按省份汇总当前配置预计生成的号码数量，写入CSV（province,middle_codes,estimated_count），
+// 按数量从多到少排列，最后一行为合计。中间码按内置省份表归属省份，表中没有的使用-province或-middle-csv给出的地区名
+func writeDensityReport(segments Segments, config Config, opts options) error {
+	prefixes := segments.All()
+	allowed := config.prefixMiddles(segments)
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	var blocks []Block
+	if opts.blockFile != "" {
+		var err error
+		if blocks, err = loadBlockFile(opts.blockFile, segments, config.MiddleCodes); err != nil {
+			return err
+		}
+		if blocks == nil {
+			blocks = []Block{}
+		}
+	}
+
+	// 按组合空间统计，prefixMiddleMap、尾号范围和分配块等与生成时一致
+	perMiddle := make(map[string]int64)
+	for _, pass := range newGenConfig(spaceOptions(opts, allowed, blocks)).passes() {
+		for _, b := range pass.buildSpace(prefixes, config.MiddleCodes).blocks {
+			perMiddle[b.middle] += int64(b.suffixes.Len())
+		}
+	}
+
+	provinceOf := make(map[string]string)
+	for _, p := range provinceMiddleCodes {
+		for _, code := range p.codes {
+			provinceOf[code] = p.name
+		}
+	}
+	type densityRow struct {
+		province string
+		codes    int
+		count    int64
+	}
+	var rows []*densityRow
+	byProvince := make(map[string]*densityRow)
+	for _, code := range config.MiddleCodes {
+		province := provinceOf[code]
+		if province == "" {
+			province = config.Regions[code]
+		}
+		if province == "" {
+			province = unknownProvince
+		}
+		row, ok := byProvince[province]
+		if !ok {
+			row = &densityRow{province: province}
+			byProvince[province] = row
+			rows = append(rows, row)
+		}
+		row.codes++
+		row.count += perMiddle[code]
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].count != rows[j].count {
+			return rows[i].count > rows[j].count
+		}
+		return rows[i].province < rows[j].province
+	})
+
+	path := opts.densityReport
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "province,middle_codes,estimated_count")
+	var totalCodes int
+	var total int64
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s,%d,%d\n", csvField(row.province), row.codes, row.count)
+		totalCodes += row.codes
+		total += row.count
+	}
+	fmt.Fprintf(w, "total,%d,%d\n", totalCodes, total)
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write to %s: %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write to %s: %v", path, err)
+	}
+	fmt.Fprintf(infoOut, "Density report written to %s: %d provinces, %d middle codes, %d numbers estimated\n", path, len(rows), totalCodes, total)
+	return nil
+}
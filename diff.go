@@ -0,0 +1,73 @@
+package main
+
+import "fmt"
+
+// -diff：不写入任何文件，比较当前配置会生成的号码与已有字典。旧文件按-dedup-mode读入集合，
+// 再逐个检查新号码：不在集合中的为新增，在集合中的为不变，旧号码中未被覆盖的为移除
+func printDiff(segments Segments, config Config, opts options) error {
+	prefixes := segments.All()
+	allowed := config.prefixMiddles(segments)
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	var blocks []Block
+	if opts.blockFile != "" {
+		var err error
+		if blocks, err = loadBlockFile(opts.blockFile, segments, config.MiddleCodes); err != nil {
+			return err
+		}
+		if blocks == nil {
+			blocks = []Block{}
+		}
+	}
+
+	stop := startHeartbeat("Loading " + opts.diff)
+	old, oldCount, err := loadNumberSet(opts.diff, opts.dedupMode, opts.dedupFPRate)
+	stop()
+	if err != nil {
+		return err
+	}
+	// 精确集合可以去掉旧文件中的重复行
+	if set, ok := old.(exactSet); ok {
+		oldCount = int64(len(set))
+	}
+
+	// 与生成时相同的模板、进制和过滤条件，保证比较的是实际会写出的号码
+	diffOpts := append(spaceOptions(opts, allowed, blocks), formatOptions(opts)...)
+	if opts.set["output-base"] && opts.outputBase != 10 {
+		diffOpts = append(diffOpts, WithOutputBase(opts.outputBase))
+	}
+	if opts.checksum != "" {
+		valid, _ := parseChecksum(opts.checksum)
+		diffOpts = append(diffOpts, WithFilter(valid))
+	}
+	if opts.blocklist != "" {
+		re, _ := parseBlocklist(opts.blocklist)
+		diffOpts = append(diffOpts, WithFilter(blocklistFilter(re)))
+	}
+	if opts.limit > 0 {
+		diffOpts = append(diffOpts, WithLimit(opts.limit))
+	}
+	var added, unchanged int64
+	stop = startHeartbeat("Comparing with " + opts.diff)
+	err = ForEachNumber(prefixes, config.MiddleCodes, func(number string) error {
+		if old.Contains(number) {
+			unchanged++
+		} else {
+			added++
+		}
+		return nil
+	}, diffOpts...)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	// 布隆过滤器无法去重计数，旧文件中的重复行会计入移除
+	removed := max(oldCount-unchanged, 0)
+	fmt.Fprintf(infoOut, "\n🔍 Diff against %s (%s mode, nothing written):\n", opts.diff, opts.dedupMode)
+	fmt.Fprintf(infoOut, "Old numbers: %d | New numbers: %d\n", oldCount, added+unchanged)
+	fmt.Fprintf(infoOut, "Added: %d | Removed: %d | Unchanged: %d\n", added, removed, unchanged)
+	if opts.dedupMode == "bloom" {
+		fmt.Fprintf(infoOut, "Note: in bloom mode about %g%% of the added numbers are counted as unchanged instead\n", opts.dedupFPRate*100)
+	}
+	return nil
+}
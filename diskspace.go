@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errFreeSpaceUnsupported = errors.New("free space query is not supported on this platform")
+
+// 比较预计输出大小与剩余空间，空间不足且未指定-force时返回错误
+func checkDiskSpace(dir string, required uint64, force bool) error {
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		logf("Notice: skipping free disk space check (%v)\n", err)
+		return nil
+	}
+	fmt.Fprintf(infoOut, "Free disk space: %.2f MB\n", float64(free)/1024/1024)
+	if required <= free {
+		return nil
+	}
+	shortfall := float64(required-free) / 1024 / 1024
+	if force {
+		logf("⚠️ Warning: estimated output exceeds free disk space by %.2f MB, continuing because -force is set\n", shortfall)
+		return nil
+	}
+	return fmt.Errorf("estimated output exceeds free disk space by %.2f MB, free up space or rerun with -force to proceed anyway", shortfall)
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package main
+
+// 当前平台不支持查询剩余空间
+func freeDiskSpace(dir string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
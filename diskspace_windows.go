@@ -0,0 +1,24 @@
+//go:build windows
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// 查询目录所在磁盘对当前用户可用的剩余空间（字节）
+func freeDiskSpace(dir string) (uint64, error) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	r, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&available)), 0, 0)
+	if r == 0 {
+		return 0, err
+	}
+	return available, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// E.164：+、国家码86和不带长途前缀0的国内号码，最多15位数字。
+// 手机号本身就是完整的国内号码（11位，不以0开头），直接加上+86即可
+const (
+	e164Template    = "+86{prefix}{middle}{suffix}"
+	e164CountryCode = "86"
+	e164MaxDigits   = 15
+)
+
+// -e164改变号码本身的格式，不能再叠加其他格式或非纯数字的输出
+var e164IncompatibleFlags = []string{"template", "separator-fuzz", "output-base", "no-prefix", "seed-file"}
+
+func checkE164Options(formats []string, opts options) error {
+	if !opts.e164 {
+		return nil
+	}
+	for _, format := range formats {
+		if format == "binary" || format == "mask" || format == "ranges" {
+			return fmt.Errorf("-e164 cannot be used with -format=%s", format)
+		}
+	}
+	for _, name := range e164IncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-e164 cannot be used with -%s", name)
+		}
+	}
+	if digits := len(e164CountryCode) + opts.layout.totalLen(); digits > e164MaxDigits {
+		return fmt.Errorf("-e164 numbers have at most %d digits, but +%s and a %d-digit number give %d",
+			e164MaxDigits, e164CountryCode, opts.layout.totalLen(), digits)
+	}
+	return nil
+}
+
+// 国内号码以0开头说明带有长途前缀，E.164中必须去掉，这里不做猜测而是直接报错
+func checkE164Prefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if prefix[0] == '0' {
+			return fmt.Errorf("prefix %s starts with the trunk prefix 0, which E.164 numbers must not contain", prefix)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestE164Format(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137", "198"}, []string{"0537"}, formatOptions(legacyOptions(t, "-e164"))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if n != 20000 || len(lines) != 20000 {
+		t.Fatalf("got %d numbers in %d lines, want 20000", n, len(lines))
+	}
+	if lines[0] != "+8613705370000" || lines[len(lines)-1] != "+8619805379999" {
+		t.Fatalf("first and last lines are %q and %q", lines[0], lines[len(lines)-1])
+	}
+	e164 := regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+	for _, line := range lines {
+		if !e164.MatchString(line) || len(line) != 14 {
+			t.Fatalf("%q is not a 13-digit E.164 number", line)
+		}
+	}
+}
+
+func TestE164Options(t *testing.T) {
+	for _, args := range [][]string{
+		{"-e164", "-template", "{prefix} {middle} {suffix}"},
+		{"-e164", "-format", "binary"},
+		{"-e164", "-no-prefix"},
+		{"-e164", "-prefix-len", "6"},
+	} {
+		if _, _, err := parseArgs(args); err == nil || !strings.Contains(err.Error(), "-e164") {
+			t.Errorf("parseArgs(%q) returned %v, want an -e164 error", args, err)
+		}
+	}
+	if err := checkE164Prefixes([]string{"137", "010"}); err == nil {
+		t.Error("a prefix with the trunk prefix 0 was accepted")
+	}
+	if err := checkE164Prefixes([]string{"137", "198"}); err != nil {
+		t.Errorf("mobile prefixes were rejected: %v", err)
+	}
+}
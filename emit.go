@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -emit-rate的号码写到标准输出，其余信息写到标准错误（infoOut），管道另一端只收到号码
+var emitOut io.Writer = os.Stdout
+
+// -emit-rate按固定速度把号码逐行写到标准输出，用于实时压测；不写文件，也不能与面向文件输出的参数同时使用
+var emitIncompatibleFlags = []string{
+	"out", "zip", "out-parallel", "append", "compress", "index", "grouped", "header", "no-trailing-newline",
+	"sample", "weights", "interleave", "seed-file", "dedup-against", "delta-against", "complement", "no-prefix", "block-file",
+	"stats", "stats-json", "report", "workers", "auto-tune", "preview", "diff",
+}
+
+// 速率单位
+var emitRateUnits = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// 解析-emit-rate，如10/s、30/m、100/h或0.5/s，返回相邻两个号码的间隔
+func parseEmitRate(rate string) (time.Duration, error) {
+	count, unit, _ := strings.Cut(rate, "/")
+	per, ok := emitRateUnits[unit]
+	n, err := strconv.ParseFloat(count, 64)
+	if !ok || err != nil || !(n > 0) {
+		return 0, fmt.Errorf("invalid -emit-rate %q (use numbers per second, minute or hour, e.g. 10/s, 30/m or 100/h)", rate)
+	}
+	interval := time.Duration(float64(per) / n)
+	if interval < time.Microsecond {
+		return 0, fmt.Errorf("-emit-rate %s is too fast to pace, write to a file instead", rate)
+	}
+	return interval, nil
+}
+
+func checkEmitOptions(opts options) error {
+	if opts.emitRate == "" {
+		return nil
+	}
+	if _, err := parseEmitRate(opts.emitRate); err != nil {
+		return err
+	}
+	for _, name := range emitIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-emit-rate cannot be used with -%s (numbers are streamed to stdout)", name)
+		}
+	}
+	if formats, err := parseFormats(opts.format); err == nil && (len(formats) > 1 || formats[0] != "txt") {
+		return fmt.Errorf("-emit-rate only writes txt lines")
+	}
+	return nil
+}
+
+// 每隔interval写出一个号码并立即交给标准输出；下游读得慢时丢弃错过的节拍，速率只会更低不会突增
+func emitNumbers(segments Segments, config Config, opts options) error {
+	interval, _ := parseEmitRate(opts.emitRate)
+	prefixes := segments.All()
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	emitOpts := append(spaceOptions(opts, config.prefixMiddles(segments), nil), formatOptions(opts)...)
+	if opts.set["output-base"] && opts.outputBase != 10 {
+		emitOpts = append(emitOpts, WithOutputBase(opts.outputBase))
+	}
+	if opts.checksum != "" {
+		valid, _ := parseChecksum(opts.checksum)
+		emitOpts = append(emitOpts, WithFilter(valid))
+	}
+	if opts.blocklist != "" {
+		re, _ := parseBlocklist(opts.blocklist)
+		emitOpts = append(emitOpts, WithFilter(blocklistFilter(re)))
+	}
+	if opts.limit > 0 {
+		emitOpts = append(emitOpts, WithLimit(opts.limit))
+	}
+	if wantsShuffle(opts) {
+		seed := randomSeed(opts)
+		logf("Shuffle seed: %d (pass -seed=%d to reproduce this order)\n", seed, seed)
+		emitOpts = append(emitOpts, WithShuffle(seed))
+	}
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	logf("📡 Emitting numbers to stdout at %s (one every %s)\n", opts.emitRate, interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var emitted int64
+	err := ForEachNumber(prefixes, config.MiddleCodes, func(number string) error {
+		if emitted > 0 {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if _, err := io.WriteString(emitOut, number+"\n"); err != nil {
+			return fmt.Errorf("failed to write to stdout: %v", err)
+		}
+		emitted++
+		return nil
+	}, emitOpts...)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logf("⏱️ Timed out after %s, %d numbers emitted\n", opts.timeout, emitted)
+		return fmt.Errorf("timed out after emitting %d numbers (-timeout %s): %w", emitted, opts.timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		return err
+	}
+	logf("✅ Emitted %d numbers\n", emitted)
+	return nil
+}
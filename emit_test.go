@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEmitRate(t *testing.T) {
+	for _, tc := range []struct {
+		rate string
+		want time.Duration
+	}{
+		{"10/s", 100 * time.Millisecond},
+		{"30/m", 2 * time.Second},
+		{"100/h", 36 * time.Second},
+		{"0.5/s", 2 * time.Second},
+	} {
+		if got, err := parseEmitRate(tc.rate); err != nil || got != tc.want {
+			t.Errorf("parseEmitRate(%q) = %v, %v, want %v", tc.rate, got, err, tc.want)
+		}
+	}
+	for _, rate := range []string{"", "10", "10/d", "0/s", "-1/s", "x/s", "NaN/s", "2000000/s"} {
+		if _, err := parseEmitRate(rate); err == nil {
+			t.Errorf("parseEmitRate(%q) was accepted", rate)
+		}
+	}
+}
+
+// 记录每行写出的时间
+type timedWriter struct {
+	times []time.Time
+	lines []string
+}
+
+func (w *timedWriter) Write(p []byte) (int, error) {
+	w.times = append(w.times, time.Now())
+	w.lines = append(w.lines, string(p))
+	return len(p), nil
+}
+
+// 相邻号码的平均间隔与速率一致
+func TestEmitNumbersInterval(t *testing.T) {
+	t.Setenv(envMiddleCodes, "")
+	w := &timedWriter{}
+	emitOut = w
+	t.Cleanup(func() { emitOut = os.Stdout })
+	opts := legacyOptions(t, "-emit-rate", "50/s", "-limit", "11")
+	var err error
+	captureStdout(t, func() {
+		err = emitNumbers(initDefaultSegments(), Config{MiddleCodes: []string{"0537"}}, opts)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.times) != 11 || w.lines[0] != "13405370000\n" || w.lines[10] != "13405370010\n" {
+		t.Fatalf("emitted %d lines: %q", len(w.lines), w.lines)
+	}
+	const interval = 20 * time.Millisecond
+	average := w.times[10].Sub(w.times[0]) / 10
+	if average < interval*9/10 || average > interval*3 {
+		t.Fatalf("average interval %s, want about %s", average, interval)
+	}
+}
+
+// -emit-rate只把号码写到emitOut，提示信息改到标准错误，不替换os.Stdout
+func TestEmitRateKeepsStdoutForNumbers(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(envMiddleCodes, "")
+	w := &timedWriter{}
+	emitOut = w
+	t.Cleanup(func() { emitOut = os.Stdout })
+	var code int
+	replaced := false
+	info := captureStdout(t, func() {
+		stdout := os.Stdout
+		code = runArgs(t, "generate", "-emit-rate", "1000/s", "-limit", "3", "-middle", "0537")
+		replaced = os.Stdout != stdout
+	})
+	if code != 0 {
+		t.Fatalf("run exited with %d", code)
+	}
+	if replaced {
+		t.Fatal("run replaced os.Stdout")
+	}
+	if info != "" {
+		t.Fatalf("messages were written to stdout: %q", info)
+	}
+	if got := strings.Join(w.lines, ""); got != "13405370000\n13405370001\n13405370002\n" {
+		t.Fatalf("emitted %q", got)
+	}
+}
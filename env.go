@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 容器中不方便挂载配置文件时，可用环境变量指定中间码和运营商。
+// 优先级：命令行参数 > 环境变量 > config.json > 默认值
+const (
+	envMiddleCodes = "NG_MIDDLE_CODES"
+	envOperators   = "NG_OPERATORS"
+)
+
+// 读取NG_MIDDLE_CODES，未设置或为空时返回nil
+func middleCodesFromEnv() ([]string, error) {
+	value := os.Getenv(envMiddleCodes)
+	if value == "" {
+		return nil, nil
+	}
+	middleCodes, err := parseMiddleCodes(value)
+	if err != nil {
+		return nil, invalidInput(fmt.Errorf("invalid %s: %v", envMiddleCodes, err))
+	}
+	fmt.Fprintf(infoOut, "Using %d %d-digit middle codes from %s: %v\n", len(middleCodes), layout.middleLen, envMiddleCodes, middleCodes)
+	return middleCodes, nil
+}
+
+// 确定使用的运营商：-operators > NG_OPERATORS > 全部运营商
+func resolveOperators(opts options) ([]string, error) {
+	if opts.operators != "" {
+		operators, err := parseOperators(opts.operators)
+		if err != nil {
+			return nil, invalidInput(fmt.Errorf("invalid -operators: %v", err))
+		}
+		return operators, nil
+	}
+	if value := os.Getenv(envOperators); value != "" {
+		operators, err := parseOperators(value)
+		if err != nil {
+			return nil, invalidInput(fmt.Errorf("invalid %s: %v", envOperators, err))
+		}
+		fmt.Fprintf(infoOut, "Using operators from %s: %v\n", envOperators, operators)
+		return operators, nil
+	}
+	return operatorOrder, nil
+}
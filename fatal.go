@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// 致命错误的类别，-json-errors时作为kind输出，并决定退出码
+const (
+	errorKindGenerate = "generate"
+	errorKindConfig   = "config"
+	errorKindInput    = "input"
+	errorKindTimeout  = "timeout"
+)
+
+// 各类别的退出码
+var exitCodes = map[string]int{
+	errorKindGenerate: 1,
+	errorKindConfig:   2,
+	errorKindInput:    3,
+	errorKindTimeout:  4,
+}
+
+// 由-json-errors设置
+var jsonErrors bool
+
+// 命令行参数或输入的中间码等无效
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string { return e.err.Error() }
+func (e inputError) Unwrap() error { return e.err }
+
+func invalidInput(err error) error {
+	if err == nil {
+		return nil
+	}
+	return inputError{err}
+}
+
+// 根据错误链判断类别：ConfigError为config，inputError为input，-timeout到期为timeout，其余为generate
+func errorKind(err error) string {
+	var configErr *ConfigError
+	var inputErr inputError
+	switch {
+	case errors.As(err, &configErr):
+		return errorKindConfig
+	case errors.As(err, &inputErr):
+		return errorKindInput
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorKindTimeout
+	}
+	return errorKindGenerate
+}
+
+// 报告致命错误并返回对应类别的退出码：默认打印可读的错误信息，
+// -json-errors时向stderr输出{"error":...,"kind":...}
+func fatal(context string, err error) int {
+	kind := errorKind(err)
+	if !jsonErrors {
+		logf("%s: %v\n", context, err)
+		return exitCodes[kind]
+	}
+	writeLog("%s: %v", context, err)
+	json.NewEncoder(os.Stderr).Encode(struct {
+		Error string `json:"error"`
+		Kind  string `json:"kind"`
+	}{fmt.Sprintf("%s: %v", context, err), kind})
+	return exitCodes[kind]
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !windows
+
+package main
+
+import "os"
+
+// 当前平台不支持文件锁，追加时只保证每次写入完整的行
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import "sync/atomic"
+
+// Filter 号码过滤条件，返回false的号码会被丢弃，通过WithFilter加入生成参数
+type Filter func(number string) bool
+
+// 丢弃集合中已有的号码，用于-dedup-against和-seed-file
+func excludeSet(set numberSet) Filter {
+	return func(number string) bool {
+		return !set.Contains(number)
+	}
+}
+
+// 统计通过f的号码数量，n可被并发更新
+func countPassed(f Filter, n *atomic.Int64) Filter {
+	return func(number string) bool {
+		if !f(number) {
+			return false
+		}
+		n.Add(1)
+		return true
+	}
+}
+
+// 统计被f丢弃的号码数量，n可被并发更新
+func countRejected(f Filter, n *atomic.Int64) Filter {
+	return func(number string) bool {
+		if f(number) {
+			return true
+		}
+		n.Add(1)
+		return false
+	}
+}
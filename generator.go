@@ -0,0 +1,662 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 进度回调的间隔（号码数）
+const progressInterval = 10000
+
+// ProgressFunc 接收已生成数量和预计总数
+type ProgressFunc func(done, total int64)
+
+type genConfig struct {
+	suffixLen     int
+	progress      ProgressFunc
+	shuffle       bool
+	seed          int64
+	sample        int64
+	prefixWeights map[string]float64
+	observe       func(prefix, middle string)
+	observeNumber func(prefix, middle string, suffix int)
+	noTrailingNL  bool
+	filters       []Filter
+	interleave    map[string]string
+	prefixMiddles map[string][]string
+	templates     []Template
+	reverseSuffix bool
+	suffixStart   int
+	suffixEnd     int // -1表示10^suffixLen-1
+	suffixStep    int
+	perPair       int
+	shard         int
+	shardCount    int
+	limit         int64
+	blocks        *blockSet
+	outputBase    int // 0或10表示十进制
+	sorted        bool
+	shuffleSuffix bool
+	suffixSeed    int64
+	ctx           context.Context
+	pause         *Pauser
+	indexStart    int64
+	groupHeader   func(middle string) string
+	maxMemory     int64
+	suffixDigits  string
+	prefixRanges  map[string]suffixBlockRange
+	suffixLens    []int // 依次使用的尾号位数，为空时只用suffixLen
+}
+
+// 达到-limit后用于提前结束遍历，不会返回给调用方
+var errLimitReached = errors.New("limit reached")
+
+// Option 生成参数的可选配置
+type Option func(*genConfig)
+
+// WithSuffixLen 设置尾号位数，尾号范围为0到10^n-1并按n位补零，默认4位
+func WithSuffixLen(n int) Option {
+	return func(c *genConfig) {
+		c.suffixLen = n
+	}
+}
+
+// WithProgress 设置进度回调：每生成10000个号码调用一次，结束时再以最终数量调用一次，
+// 回调在生成协程中同步执行，界面可自行节流
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *genConfig) {
+		c.progress = fn
+	}
+}
+
+// WithShuffle 按seed确定的随机顺序输出全部号码，相同seed得到相同顺序，
+// 置换按下标即时计算，不占用额外内存
+func WithShuffle(seed int64) Option {
+	return func(c *genConfig) {
+		c.shuffle = true
+		c.seed = seed
+	}
+}
+
+// WithoutTrailingNewline 最后一个号码后不输出换行符
+func WithoutTrailingNewline() Option {
+	return func(c *genConfig) {
+		c.noTrailingNL = true
+	}
+}
+
+// WithInterleave 按分组轮流输出号码（每组一个），groups为号段到组名（如运营商）的映射，
+// 只改变输出顺序不改变号码集合；打乱或抽样时不生效
+func WithInterleave(groups map[string]string) Option {
+	return func(c *genConfig) {
+		c.interleave = groups
+	}
+}
+
+// WithSuffixRange 只生成start到end（含）之间、间隔为step的尾号，默认为0到10^n-1、间隔1
+func WithSuffixRange(start, end, step int) Option {
+	return func(c *genConfig) {
+		c.suffixStart = start
+		c.suffixEnd = end
+		c.suffixStep = step
+	}
+}
+
+// WithSuffixDigits 尾号的每一位只使用digits中的数字（从小到大排列，如"08"），
+// 每个组合len(digits)^n个尾号，代替WithSuffixRange
+func WithSuffixDigits(digits string) Option {
+	return func(c *genConfig) {
+		c.suffixDigits = digits
+	}
+}
+
+// WithPrefixSuffixRanges 列出的号段只生成ranges中对应范围内的尾号，代替WithSuffixRange的start和end，
+// 间隔、分片等其余尾号参数仍然生效；未列出的号段使用WithSuffixRange的范围
+func WithPrefixSuffixRanges(ranges map[string]suffixBlockRange) Option {
+	return func(c *genConfig) {
+		c.prefixRanges = ranges
+	}
+}
+
+// WithSuffixLenRange 依次以minLen到maxLen（含）位的尾号生成，每个位数生成完整的尾号空间并按该位数补零，
+// 得到不同长度的号码；代替WithSuffixLen和WithSuffixRange的start和end
+func WithSuffixLenRange(minLen, maxLen int) Option {
+	return func(c *genConfig) {
+		c.suffixLens = nil
+		for n := minLen; n <= maxLen; n++ {
+			c.suffixLens = append(c.suffixLens, n)
+		}
+	}
+}
+
+// 按尾号位数拆分的各次遍历，未设置WithSuffixLenRange时只有一次
+func (c genConfig) passes() []genConfig {
+	if len(c.suffixLens) == 0 {
+		return []genConfig{c}
+	}
+	passes := make([]genConfig, len(c.suffixLens))
+	for i, n := range c.suffixLens {
+		passes[i] = c
+		passes[i].suffixLen, passes[i].suffixStart, passes[i].suffixEnd = n, 0, -1
+	}
+	return passes
+}
+
+// WithLimit 输出n个号码（过滤后）后停止，n<=0表示不限制
+func WithLimit(n int64) Option {
+	return func(c *genConfig) {
+		c.limit = n
+	}
+}
+
+// WithShard 只生成尾号除以m余r的号码，m台机器分别使用r=0..m-1即可不重不漏地覆盖全部号码
+func WithShard(r, m int) Option {
+	return func(c *genConfig) {
+		c.shard = r
+		c.shardCount = m
+	}
+}
+
+// WithPerPair 每个号段+中间码组合只输出前k个尾号，使输出均匀分布在所有组合上
+func WithPerPair(k int) Option {
+	return func(c *genConfig) {
+		c.perPair = k
+	}
+}
+
+// WithReverseSuffix 每个号段+中间码组合内尾号从大到小输出，如9999到0000，号码集合不变
+func WithReverseSuffix() Option {
+	return func(c *genConfig) {
+		c.reverseSuffix = true
+	}
+}
+
+// WithPrefixMiddleCodes 限制部分号段只与指定的中间码组合，未列出的号段使用全部中间码
+func WithPrefixMiddleCodes(allowed map[string][]string) Option {
+	return func(c *genConfig) {
+		c.prefixMiddles = allowed
+	}
+}
+
+// WithTemplate 按模板拼接号码，如{prefix}-{middle}-{suffix:4}，过滤条件和编码器拿到的都是拼接后的号码
+func WithTemplate(t Template) Option {
+	return func(c *genConfig) {
+		c.templates = []Template{t}
+	}
+}
+
+// WithTemplateCycle 依次轮流使用多个模板拼接号码，第i个号码使用templates[i%len(templates)]
+func WithTemplateCycle(templates []Template) Option {
+	return func(c *genConfig) {
+		c.templates = templates
+	}
+}
+
+// WithIndexStart 设置第一个号码的Record.Index，之后每输出一个号码加1；
+// 拆分输出时各部分传入前面各部分的号码总数，序号即可跨文件连续
+func WithIndexStart(n int64) Option {
+	return func(c *genConfig) {
+		c.indexStart = n
+	}
+}
+
+// WithGroupHeaders 中间码与上一个号码不同时，先输出一行header(middle)作为分组标题；
+// 标题行不计入号码数量，二进制输出中不写
+func WithGroupHeaders(header func(middle string) string) Option {
+	return func(c *genConfig) {
+		c.groupHeader = header
+	}
+}
+
+// WithMaxMemory 限制GenerateParallel在途批次（已分发但尚未写出）的总字节数，
+// 写入跟不上时拼接协程等待；0表示默认的每个协程2批
+func WithMaxMemory(bytes int64) Option {
+	return func(c *genConfig) {
+		c.maxMemory = bytes
+	}
+}
+
+// WithContext ctx结束（取消或超时）时停止生成：已生成的号码照常刷新到输出（包括末尾的换行符），
+// 然后返回已写入的数量和ctx.Err()
+func WithContext(ctx context.Context) Option {
+	return func(c *genConfig) {
+		c.ctx = ctx
+	}
+}
+
+// 是否为WithContext的ctx结束导致的错误
+func (c genConfig) cancelled(err error) bool {
+	return c.ctx != nil && err != nil && err == c.ctx.Err()
+}
+
+// WithSuffixShuffle 每个号段+中间码组合内的尾号按随机顺序输出（仍然各输出一次），组合之间的顺序不变；
+// 每个组合的置换由seed和组合本身确定，相同seed得到相同顺序
+func WithSuffixShuffle(seed int64) Option {
+	return func(c *genConfig) {
+		c.shuffleSuffix = true
+		c.suffixSeed = seed
+	}
+}
+
+// WithSorted 按数值从小到大输出：号段和中间码排序后再组合。各部分位数固定，
+// 按字符串排序即为按数值排序，因此不需要额外的内存或外部排序
+func WithSorted() Option {
+	return func(c *genConfig) {
+		c.sorted = true
+	}
+}
+
+// WithOutputBase 把通过过滤的号码转换为base进制（2-36）输出，按号码位数可表示的最大值补零到固定宽度，
+// 过滤条件仍作用于十进制号码
+func WithOutputBase(base int) Option {
+	return func(c *genConfig) {
+		c.outputBase = base
+	}
+}
+
+// 按WithOutputBase转换进制，如base=16时13705370000输出为0330e75990
+func (c genConfig) rebase(number string) string {
+	if c.outputBase == 0 || c.outputBase == 10 {
+		return number
+	}
+	return formatBase(number, c.outputBase)
+}
+
+// 把十进制数字串转换为base进制，补零到同样位数的十进制数所需的最大宽度，保证各行等宽
+func formatBase(number string, base int) string {
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || len(number) > 19 {
+		return number
+	}
+	max := uint64(1)
+	for range number {
+		max *= 10
+	}
+	width := len(strconv.FormatUint(max-1, base))
+	s := strconv.FormatUint(n, base)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+
+// WithFilter 追加号码过滤条件，可多次使用，号码需通过全部条件才会输出。
+// 过滤条件对每个候选号码（模板格式化之后、进制转换之前）调用一次，吞吐量随过滤开销成比例下降；
+// 使用GenerateParallel时会被多个协程并发调用
+func WithFilter(f Filter) Option {
+	return func(c *genConfig) {
+		c.filters = append(c.filters, f)
+	}
+}
+
+func (c genConfig) keep(number string) bool {
+	for _, filter := range c.filters {
+		if !filter(number) {
+			return false
+		}
+	}
+	return true
+}
+
+// 每写入一个号码时回调，供命令行统计各号段、中间码的数量
+func withObserver(fn func(prefix, middle string)) Option {
+	return func(c *genConfig) {
+		c.observe = fn
+	}
+}
+
+// 每写入一个号码时带尾号回调，供-stats统计；GenerateParallel按批次写入，不支持此回调
+func withNumberObserver(fn func(prefix, middle string, suffix int)) Option {
+	return func(c *genConfig) {
+		c.observeNumber = fn
+	}
+}
+
+func newGenConfig(opts []Option) genConfig {
+	c := genConfig{suffixLen: 4, suffixEnd: -1, suffixStep: 1}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func pow10(n int) int {
+	result := 1
+	for i := 0; i < n; i++ {
+		result *= 10
+	}
+	return result
+}
+
+// 组合遍历的回调，参数为号码的三个组成部分
+type visitFunc func(prefix, middle string, suffix int) error
+
+// 按配置选择遍历方式：抽样、打乱、交错或顺序遍历
+func (c genConfig) walk(prefixes, middleCodes []string, visit visitFunc) error {
+	if c.suffixLen < 1 {
+		return fmt.Errorf("suffix length must be positive, got %d", c.suffixLen)
+	}
+	s := c.buildSpace(prefixes, middleCodes)
+	if c.ctx != nil {
+		// 每1024个组合检查一次ctx，过滤掉的号码也计入，避免过滤很严时迟迟不检查
+		inner, visited := visit, 0
+		visit = func(prefix, middle string, suffix int) error {
+			visited++
+			if visited%1024 == 0 {
+				if err := c.ctx.Err(); err != nil {
+					return err
+				}
+			}
+			return inner(prefix, middle, suffix)
+		}
+	}
+	if c.sample > 0 {
+		return walkSampled(s, c, visit)
+	}
+	if c.shuffle {
+		return walkShuffled(s, c.seed, visit)
+	}
+	if c.interleave != nil {
+		return walkInterleaved(s, c.interleave, visit)
+	}
+	for _, b := range s.blocks {
+		for i := 0; i < b.suffixes.Len(); i++ {
+			if err := visit(b.prefix, b.middle, b.suffixes.At(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EstimateCount 返回按给定参数生成的号码数量（不含过滤）：每个号段+中间码组合生成
+// suffixStart到suffixEnd（含）之间、间隔为step的尾号。参数无效（step<1或end<start）时返回0，
+// 结果超出int64时返回math.MaxInt64
+func EstimateCount(prefixes, middleCodes []string, suffixStart, suffixEnd, step int) int64 {
+	if step < 1 || suffixEnd < suffixStart {
+		return 0
+	}
+	perPair := int64(suffixEnd-suffixStart)/int64(step) + 1
+	pairs := int64(len(prefixes)) * int64(len(middleCodes))
+	if pairs == 0 {
+		return 0
+	}
+	if perPair > math.MaxInt64/pairs {
+		return math.MaxInt64
+	}
+	return pairs * perPair
+}
+
+// 尾号范围，结束值未设置时取该位数的最大值
+func (c genConfig) suffixBounds() (int, int, int) {
+	end := c.suffixEnd
+	if end < 0 {
+		end = pow10(c.suffixLen) - 1
+	}
+	return c.suffixStart, end, c.suffixStep
+}
+
+// 预计生成的号码数量（过滤前）
+func (c genConfig) total(prefixes, middleCodes []string) int64 {
+	var total int64
+	for _, pass := range c.passes() {
+		total += int64(pass.buildSpace(prefixes, middleCodes).total)
+	}
+	if c.sample > 0 && c.sample < total {
+		total = c.sample
+	}
+	if c.limit > 0 && c.limit < total {
+		total = c.limit
+	}
+	return total
+}
+
+// 返回本次生成使用的号码拼接函数，多个模板时按调用次数轮流使用
+func (c genConfig) formatter() func(prefix, middle string, suffix int) string {
+	switch len(c.templates) {
+	case 0:
+		return func(prefix, middle string, suffix int) string {
+			return prefix + middle + fmt.Sprintf("%0*d", c.suffixLen, suffix)
+		}
+	case 1:
+		return func(prefix, middle string, suffix int) string {
+			return c.templates[0].format(prefix, middle, suffix, c.suffixLen)
+		}
+	}
+	next := 0
+	return func(prefix, middle string, suffix int) string {
+		t := c.templates[next]
+		next = (next + 1) % len(c.templates)
+		return t.format(prefix, middle, suffix, c.suffixLen)
+	}
+}
+
+// 逐个生成通过过滤的号码，按进制转换后的号码及其组成部分回调fn，依次处理各个尾号位数；
+// 达到limit时返回errLimitReached。ForEachNumber和GenerateMulti共用这一遍历
+func (c genConfig) eachNumber(prefixes, middleCodes []string, fn func(number, prefix, middle string, suffix int) error) error {
+	var format func(prefix, middle string, suffix int) string
+	var done int64
+	visit := func(prefix, middle string, suffix int) error {
+		if c.limit > 0 && done == c.limit {
+			return errLimitReached
+		}
+		number := format(prefix, middle, suffix)
+		if !c.keep(number) {
+			return nil
+		}
+		done++
+		return fn(c.rebase(number), prefix, middle, suffix)
+	}
+	for _, pass := range c.passes() {
+		format = pass.formatter()
+		if err := pass.walk(prefixes, middleCodes, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachNumber 按号段、中间码、尾号从小到大的顺序逐个生成号码并回调fn，
+// fn返回错误时立即停止并原样返回该错误
+func ForEachNumber(prefixes, middleCodes []string, fn func(number string) error, opts ...Option) error {
+	err := newGenConfig(opts).eachNumber(prefixes, middleCodes, func(number, _, _ string, _ int) error {
+		return fn(number)
+	})
+	if err == errLimitReached {
+		return nil
+	}
+	return err
+}
+
+// 按随机置换后的下标依次还原号码
+func walkShuffled(s *space, seed int64, visit visitFunc) error {
+	perm := newPermutation(s.total, seed)
+	for i := uint64(0); i < s.total; i++ {
+		prefix, middle, suffix := s.at(perm.at(i))
+		if err := visit(prefix, middle, suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 每组内部按顺序遍历，组之间轮流各取一个号码，直到所有组都取完
+func walkInterleaved(s *space, groups map[string]string, visit visitFunc) error {
+	type cursor struct {
+		blocks []pairBlock
+		space  *space
+		pos    uint64
+	}
+	var order []*cursor
+	byGroup := make(map[string]*cursor)
+	for _, b := range s.blocks {
+		cur, ok := byGroup[groups[b.prefix]]
+		if !ok {
+			cur = &cursor{}
+			byGroup[groups[b.prefix]] = cur
+			order = append(order, cur)
+		}
+		cur.blocks = append(cur.blocks, b)
+	}
+	for _, cur := range order {
+		cur.space = newSpace(cur.blocks)
+	}
+
+	for active := len(order); active > 0; {
+		active = 0
+		for _, cur := range order {
+			if cur.pos == cur.space.total {
+				continue
+			}
+			active++
+			prefix, middle, suffix := cur.space.at(cur.pos)
+			cur.pos++
+			if err := visit(prefix, middle, suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Record 一个生成的号码及其组成部分，Index为号码在输出中的序号（从WithIndexStart设置的值开始，默认为0）
+type Record struct {
+	Number string
+	Prefix string
+	Middle string
+	Suffix int
+	Index  int64
+}
+
+// Encoder 把号码编码为一行输出（不含换行符），为nil时直接输出号码
+type Encoder func(r Record) string
+
+// Output 一个输出目标及其编码方式；Binary为true时每个号码写成8字节小端序整数，
+// 不使用Encode也不写换行符
+type Output struct {
+	W      io.Writer
+	Encode Encoder
+	Binary bool
+}
+
+// Generate 将号码逐行写入w，返回写入的号码数量。每行为号段+中间码+补零尾号并以\n结尾，
+// 如号段137、中间码0537依次输出13705370000到13705379999。w会被缓冲，
+// 每次进度回调前都会先刷新缓冲区，保证回调时数据已交给w
+func Generate(w io.Writer, prefixes, middleCodes []string, opts ...Option) (int64, error) {
+	return GenerateMulti([]Output{{W: w}}, prefixes, middleCodes, opts...)
+}
+
+// GenerateMulti 只遍历一次组合空间，把每个号码按各自的编码写入所有输出，
+// 返回每个输出写入的号码数量（各输出相同）
+func GenerateMulti(outputs []Output, prefixes, middleCodes []string, opts ...Option) (int64, error) {
+	c := newGenConfig(opts)
+	total := c.total(prefixes, middleCodes)
+	writers := make([]*bufio.Writer, len(outputs))
+	for i, out := range outputs {
+		writers[i] = bufio.NewWriter(out.W)
+	}
+	flush := func() error {
+		for _, writer := range writers {
+			if err := writer.Flush(); err != nil {
+				return fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+		return nil
+	}
+
+	var done int64
+	var packed []byte
+	lastMiddle := ""
+	// 换行符写在每行之前（第一行除外），结束时再决定是否补上最后一个换行符
+	err := c.eachNumber(prefixes, middleCodes, func(number, prefix, middle string, suffix int) error {
+		record := Record{Number: number, Prefix: prefix, Middle: middle, Suffix: suffix, Index: c.indexStart + done}
+		header := ""
+		if c.groupHeader != nil && (done == 0 || middle != lastMiddle) {
+			header = c.groupHeader(middle)
+			lastMiddle = middle
+		}
+		for i, writer := range writers {
+			if outputs[i].Binary {
+				packed = appendBinaryNumber(packed[:0], number)
+				if _, err := writer.Write(packed); err != nil {
+					return fmt.Errorf("failed to write to file: %v", err)
+				}
+				continue
+			}
+			if header != "" {
+				if done > 0 {
+					if err := writer.WriteByte('\n'); err != nil {
+						return fmt.Errorf("failed to write to file: %v", err)
+					}
+				}
+				if _, err := writer.WriteString(header); err != nil {
+					return fmt.Errorf("failed to write to file: %v", err)
+				}
+			}
+			if done > 0 || header != "" {
+				if err := writer.WriteByte('\n'); err != nil {
+					return fmt.Errorf("failed to write to file: %v", err)
+				}
+			}
+			line := number
+			if outputs[i].Encode != nil {
+				line = outputs[i].Encode(record)
+			}
+			if _, err := writer.WriteString(line); err != nil {
+				return fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+		if c.observe != nil {
+			c.observe(prefix, middle)
+		}
+		if c.observeNumber != nil {
+			c.observeNumber(prefix, middle, suffix)
+		}
+		done++
+		if done%progressInterval == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			if c.progress != nil {
+				c.progress(done, total)
+			}
+		}
+		if c.pause != nil && c.pause.Paused() {
+			if err := flush(); err != nil {
+				return err
+			}
+			return c.pause.wait(c.ctx)
+		}
+		return nil
+	})
+	var cancelErr error
+	if c.cancelled(err) {
+		cancelErr, err = err, nil
+	}
+	if err != nil && err != errLimitReached {
+		return done, err
+	}
+	if done > 0 && !c.noTrailingNL {
+		for i, writer := range writers {
+			if outputs[i].Binary {
+				continue
+			}
+			if err := writer.WriteByte('\n'); err != nil {
+				return done, fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return done, err
+	}
+	if c.progress != nil && done%progressInterval != 0 {
+		c.progress(done, total)
+	}
+	return done, cancelErr
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateFormat(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137"}, []string{"0537"})
+	if err != nil {
+		t.Fatalf("Generate returned %v", err)
+	}
+	if n != 10000 {
+		t.Fatalf("Generate reported %d numbers, want 10000", n)
+	}
+	scanner := bufio.NewScanner(&buf)
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 10000 {
+		t.Fatalf("got %d lines, want 10000", len(lines))
+	}
+	if lines[0] != "13705370000" {
+		t.Errorf("first line %q, want 13705370000", lines[0])
+	}
+	if last := lines[len(lines)-1]; last != "13705379999" {
+		t.Errorf("last line %q, want 13705379999", last)
+	}
+	for i, line := range lines {
+		if len(line) != 11 {
+			t.Fatalf("line %d %q is not 11 digits", i, line)
+		}
+		for _, ch := range line {
+			if ch < '0' || ch > '9' {
+				t.Fatalf("line %d %q is not 11 digits", i, line)
+			}
+		}
+	}
+}
+
+func TestForEachNumberCountsInvocations(t *testing.T) {
+	calls := 0
+	err := ForEachNumber([]string{"137", "138"}, []string{"0537", "0538"}, func(number string) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachNumber returned %v", err)
+	}
+	if calls != 40000 {
+		t.Fatalf("got %d calls, want 40000", calls)
+	}
+}
+
+func TestForEachNumberStopsOnError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ForEachNumber([]string{"137"}, []string{"0537"}, func(number string) error {
+		calls++
+		if calls == 3 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("got error %v, want %v", err, stop)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestForEachNumberLimit(t *testing.T) {
+	calls := 0
+	err := ForEachNumber([]string{"137"}, []string{"0537"}, func(number string) error {
+		calls++
+		return nil
+	}, WithLimit(25))
+	if err != nil {
+		t.Fatalf("ForEachNumber returned %v", err)
+	}
+	if calls != 25 {
+		t.Fatalf("got %d calls, want 25", calls)
+	}
+}
+
+func TestGenerateTrailingNewline(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		opts     []Option
+		want     bool
+		newlines int
+	}{
+		{"default", nil, true, 10000},
+		{"no trailing newline", []Option{WithoutTrailingNewline()}, false, 9999},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "phonedict.txt")
+			file, err := os.Create(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Generate(file, []string{"137"}, []string{"0537"}, tc.opts...); err != nil {
+				t.Fatalf("Generate returned %v", err)
+			}
+			if err := file.Close(); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.HasSuffix(data, []byte("\n")); got != tc.want {
+				t.Fatalf("file ends with newline = %v, want %v", got, tc.want)
+			}
+			if got := bytes.Count(data, []byte("\n")); got != tc.newlines {
+				t.Fatalf("got %d newlines, want %d", got, tc.newlines)
+			}
+		})
+	}
+}
+
+func TestGenerateInterleave(t *testing.T) {
+	segments := initDefaultSegments()
+	operators := segments.Operators()
+	prefixes := segments.All()
+	var buf bytes.Buffer
+	if _, err := Generate(&buf, prefixes, []string{"0537"}, WithSuffixRange(0, 9, 1), WithInterleave(operators)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(buf.String())
+	seen := make(map[string]bool)
+	for _, line := range lines[:3] {
+		seen[operators[line[:3]]] = true
+	}
+	for _, operator := range operatorOrder {
+		if !seen[operator] {
+			t.Errorf("first 3 lines %v contain no %s number", lines[:3], operator)
+		}
+	}
+
+	var plain bytes.Buffer
+	if _, err := Generate(&plain, prefixes, []string{"0537"}, WithSuffixRange(0, 9, 1)); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Fields(plain.String())
+	slices.Sort(lines)
+	slices.Sort(want)
+	if !slices.Equal(lines, want) {
+		t.Fatal("interleaved output is not the same set of numbers")
+	}
+}
+
+func TestGenerateReverseSuffix(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		opts        []Option
+		first, last string
+		count       int
+	}{
+		{"full range", []Option{WithReverseSuffix()}, "13705379999", "13705370000", 10000},
+		{"suffix range", []Option{WithReverseSuffix(), WithSuffixRange(100, 200, 1)}, "13705370200", "13705370100", 101},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := Generate(&buf, []string{"137"}, []string{"0537"}, tc.opts...); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Fields(buf.String())
+			if len(lines) != tc.count {
+				t.Fatalf("got %d lines, want %d", len(lines), tc.count)
+			}
+			if lines[0] != tc.first || lines[len(lines)-1] != tc.last {
+				t.Fatalf("got %s..%s, want %s..%s", lines[0], lines[len(lines)-1], tc.first, tc.last)
+			}
+		})
+	}
+}
+
+func TestEstimateCount(t *testing.T) {
+	two := []string{"137", "138"}
+	middles := []string{"0537", "0100", "0210"}
+	for _, tc := range []struct {
+		name                  string
+		prefixes, middleCodes []string
+		start, end, step      int
+		want                  int64
+	}{
+		{"full range", two, middles, 0, 9999, 1, 60000},
+		{"step", two, middles, 0, 9999, 10, 6000},
+		{"step not dividing range", two, middles, 0, 10, 3, 24},
+		{"step larger than range", two, middles, 5, 9, 100, 6},
+		{"single suffix", two, middles, 7, 7, 1, 6},
+		{"end before start", two, middles, 10, 9, 1, 0},
+		{"zero step", two, middles, 0, 9999, 0, 0},
+		{"no prefixes", nil, middles, 0, 9999, 1, 0},
+		{"no middle codes", two, nil, 0, 9999, 1, 0},
+		{"overflow", make([]string, 1<<20), make([]string, 1<<20), 0, math.MaxInt32, 1, math.MaxInt64},
+	} {
+		got := EstimateCount(tc.prefixes, tc.middleCodes, tc.start, tc.end, tc.step)
+		if got != tc.want {
+			t.Errorf("%s: EstimateCount = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEstimateCountMatchesGenerate(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137"}, []string{"0537"}, WithSuffixRange(13, 9000, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := EstimateCount([]string{"137"}, []string{"0537"}, 13, 9000, 7); n != want {
+		t.Fatalf("Generate wrote %d numbers, EstimateCount says %d", n, want)
+	}
+}
+
+func TestGeneratePerPair(t *testing.T) {
+	prefixes := []string{"137", "138", "189"}
+	middleCodes := []string{"0537", "0100"}
+	var buf bytes.Buffer
+	n, err := Generate(&buf, prefixes, middleCodes, WithPerPair(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len(prefixes) * len(middleCodes) * 7); n != want {
+		t.Fatalf("wrote %d numbers, want %d", n, want)
+	}
+	perPair := make(map[string]int)
+	for _, line := range strings.Fields(buf.String()) {
+		perPair[line[:7]]++
+		if suffix := line[7:]; suffix > "0006" {
+			t.Errorf("%s is not among the first 7 suffixes", line)
+		}
+	}
+	if len(perPair) != len(prefixes)*len(middleCodes) {
+		t.Fatalf("got %d pairs, want %d", len(perPair), len(prefixes)*len(middleCodes))
+	}
+	for pair, count := range perPair {
+		if count != 7 {
+			t.Errorf("pair %s contributed %d numbers, want 7", pair, count)
+		}
+	}
+}
+
+func TestGenerateShardsCoverAllNumbers(t *testing.T) {
+	prefixes := []string{"137", "189"}
+	middleCodes := []string{"0537", "0100"}
+	var full bytes.Buffer
+	if _, err := Generate(&full, prefixes, middleCodes); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Fields(full.String())
+
+	const m = 3
+	seen := make(map[string]int)
+	for r := 0; r < m; r++ {
+		var buf bytes.Buffer
+		if _, err := Generate(&buf, prefixes, middleCodes, WithShard(r, m)); err != nil {
+			t.Fatal(err)
+		}
+		for _, number := range strings.Fields(buf.String()) {
+			seen[number]++
+		}
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("shards produced %d distinct numbers, want %d", len(seen), len(want))
+	}
+	for _, number := range want {
+		if seen[number] != 1 {
+			t.Fatalf("%s appears in %d shards, want 1", number, seen[number])
+		}
+	}
+}
+
+func TestGenerateSorted(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Generate(&buf, []string{"189", "137", "150"}, []string{"0537", "0100", "2000"}, WithSuffixRange(0, 99, 1), WithSorted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(buf.String())
+	if len(lines) != 900 {
+		t.Fatalf("got %d lines, want 900", len(lines))
+	}
+	for i := 1; i < len(lines); i++ {
+		if lines[i-1] >= lines[i] {
+			t.Fatalf("line %d %s is not greater than %s", i, lines[i], lines[i-1])
+		}
+	}
+	if lines[0] != "13701000000" || lines[len(lines)-1] != "18920000099" {
+		t.Fatalf("got %s..%s", lines[0], lines[len(lines)-1])
+	}
+}
+
+func TestGenerateTimeoutFlushesPartialOutput(t *testing.T) {
+	middleCodes, err := parseMiddleCodes("0000-9999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137", "138"}, middleCodes, WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Generate returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	if n == 0 || n >= 2*10000*10000 {
+		t.Fatalf("Generate wrote %d numbers before the timeout", n)
+	}
+	if got := int64(bytes.Count(buf.Bytes(), []byte("\n"))); got != n {
+		t.Fatalf("buffer holds %d lines, Generate reported %d", got, n)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		t.Fatal("partial output does not end with a complete line")
+	}
+}
+
+func TestGenerateShuffleSuffix(t *testing.T) {
+	prefixes := []string{"137", "189"}
+	middleCodes := []string{"0537", "0100"}
+	var buf bytes.Buffer
+	if _, err := Generate(&buf, prefixes, middleCodes, WithSuffixShuffle(7)); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(buf.String())
+	if len(lines) != 40000 {
+		t.Fatalf("got %d lines, want 40000", len(lines))
+	}
+	// 组合之间的顺序不变，每个组合内的尾号各出现一次
+	for i, pair := range []string{"1370537", "1370100", "1890537", "1890100"} {
+		block := lines[i*10000 : (i+1)*10000]
+		seen := make(map[string]bool)
+		ordered := true
+		for j, line := range block {
+			if line[:7] != pair {
+				t.Fatalf("line %d %s does not belong to pair %s", i*10000+j, line, pair)
+			}
+			seen[line[7:]] = true
+			ordered = ordered && line[7:] == fmt.Sprintf("%04d", j)
+		}
+		if len(seen) != 10000 {
+			t.Errorf("pair %s has %d distinct suffixes, want 10000", pair, len(seen))
+		}
+		if ordered {
+			t.Errorf("pair %s suffixes are not shuffled", pair)
+		}
+	}
+}
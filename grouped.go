@@ -0,0 +1,44 @@
+package main
+
+import "fmt"
+
+// -grouped要求号码按号段、中间码的顺序连续输出，且只有txt能容纳注释行
+func checkGroupedOptions(formats []string, opts options) error {
+	if !opts.grouped {
+		return nil
+	}
+	for _, format := range formats {
+		if format != "txt" {
+			return fmt.Errorf("-grouped only works with -format=txt (the # header lines would break %s)", format)
+		}
+	}
+	if opts.interleave || opts.sample > 0 || wantsShuffle(opts) {
+		return fmt.Errorf("-grouped cannot be combined with -interleave, -sample or -shuffle/-seed/-daily-seed (numbers of a middle code are not consecutive)")
+	}
+	return nil
+}
+
+// 中间码所属地区：优先使用-middle-csv或-province给出的名称，否则按内置的省份区号表查找
+func middleRegion(code string, regions map[string]string) string {
+	if region := regions[code]; region != "" {
+		return region
+	}
+	for _, p := range provinceMiddleCodes {
+		for _, c := range p.codes {
+			if c == code {
+				return p.name
+			}
+		}
+	}
+	return ""
+}
+
+// 分组标题行，如"### 0537 (Jining) ###"，地区未知时省略括号部分
+func groupHeaderLine(regions map[string]string) func(middle string) string {
+	return func(middle string) string {
+		if region := middleRegion(middle, regions); region != "" {
+			return fmt.Sprintf("### %s (%s) ###", middle, region)
+		}
+		return fmt.Sprintf("### %s ###", middle)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// 每次中间码变化前恰好有一行标题，标题不计入号码数
+func TestGroupHeaderPlacement(t *testing.T) {
+	var buf bytes.Buffer
+	regions := map[string]string{"0537": "Jining"}
+	n, err := Generate(&buf, []string{"137", "138"}, []string{"0537", "0538", "9999"},
+		WithGroupHeaders(groupHeaderLine(regions)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 60000 {
+		t.Fatalf("Generate reported %d numbers, want 60000 (headers must not be counted)", n)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 60006 {
+		t.Fatalf("got %d lines, want 60000 numbers and 6 headers", len(lines))
+	}
+	want := []struct {
+		line   int
+		header string
+	}{
+		{0, "### 0537 (Jining) ###"},
+		{10001, "### 0538 (Shandong) ###"},
+		{20002, "### 9999 ###"},
+		{30003, "### 0537 (Jining) ###"},
+		{40004, "### 0538 (Shandong) ###"},
+		{50005, "### 9999 ###"},
+	}
+	for _, w := range want {
+		if lines[w.line] != w.header {
+			t.Errorf("line %d is %q, want %q", w.line, lines[w.line], w.header)
+		}
+	}
+	for i, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if prev := lines[max(i-1, 0)]; i > 0 && !strings.HasPrefix(prev, "#") && prev[3:7] != line[3:7] {
+			t.Fatalf("line %d %q starts a new middle code without a header", i, line)
+		}
+	}
+	if lines[1] != "13705370000" || lines[30004] != "13805370000" {
+		t.Errorf("numbers after headers are %q and %q", lines[1], lines[30004])
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// 心跳间隔
+const heartbeatInterval = time.Second
+
+// 在耗时的准备阶段每秒向stderr打印一个旋转符号，返回的stop会清除该行并等待协程退出，
+// 可重复调用；stderr不是终端时什么都不做
+func startHeartbeat(label string) (stop func()) {
+	if !isTerminal(os.Stderr) {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		frames := `|/-\`
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				fmt.Fprint(os.Stderr, "\r\033[K")
+				return
+			case <-ticker.C:
+				fmt.Fprintf(os.Stderr, "\r%s %c", label, frames[i%len(frames)])
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 输入已结束（EOF或读取失败），菜单按退出处理，避免管道输入读完后反复提示
+var errNoInput = errors.New("no more input")
+
+// 交互式菜单：从in读取输入，提示信息写入out，用户选择退出或输入结束时返回最后一次生成的错误。
+// 生成过程本身的输出仍写到标准输出
+func runInteractive(in io.Reader, out io.Writer, segments Segments, opts options) error {
+	// 只有终端输入才在生成期间读取键盘，管道和文件输入保持逐行应答菜单
+	if f, ok := in.(*os.File); ok && isTerminal(f) {
+		in, opts.keys = newKeyControl(in, out)
+	}
+	scanner := bufio.NewScanner(in)
+	// 设置了NG_MIDDLE_CODES时第一轮直接使用，不再询问输入方式
+	envCodes, err := middleCodesFromEnv()
+	if err != nil {
+		fmt.Fprintf(out, "Ignoring %s: %v\n", envMiddleCodes, err)
+	}
+	var genErr error
+	// 使用循环代替递归调用main，避免栈溢出和资源泄漏
+	for {
+		config := Config{MiddleCodes: envCodes}
+		if envCodes != nil {
+			envCodes = nil
+		} else if config, err = selectMiddleCodes(scanner, out, opts.defaultMiddle); err != nil {
+			if errors.Is(err, errNoInput) {
+				fmt.Fprintln(out, "\nInput ended, exiting program...")
+				return genErr
+			}
+			fmt.Fprintf(out, "Failed to get middle codes: %v\n", err)
+			continue
+		}
+
+		limit, err := askLimit(scanner, out)
+		if errors.Is(err, errNoInput) {
+			fmt.Fprintln(out, "\nInput ended, exiting program...")
+			return genErr
+		}
+		runOpts := opts
+		if limit > 0 {
+			runOpts.limit = limit
+		}
+
+		_, genErr = generatePhoneNumbers(segments, config, runOpts)
+		if genErr != nil {
+			fmt.Fprintf(out, "Phone number generation failed: %v\n", genErr)
+			writeLog("Phone number generation failed: %v", genErr)
+		} else {
+			fmt.Fprintln(out, "\n✅ Phone numbers have been successfully exported")
+		}
+
+		// 询问是否退出
+		if !askToContinue(scanner, out) {
+			fmt.Fprintln(out, "Exiting program...")
+			return genErr
+		}
+		fmt.Fprintln(out, "-------------------------- Restart --------------------------")
+	}
+}
+
+// 选择中间码输入方式，提取为独立函数
+func selectMiddleCodes(scanner *bufio.Scanner, out io.Writer, defaultMiddle string) (Config, error) {
+	fmt.Fprintf(out, "\nPlease select %d-digit middle code input method:\n", layout.middleLen)
+	if autoCreateConfig {
+		fmt.Fprintf(out, "1. Read from %s (file will be auto-created if it doesn't exist)\n", configFile)
+	} else {
+		fmt.Fprintf(out, "1. Read from %s (must already exist, -no-auto-create is set)\n", configFile)
+	}
+	fmt.Fprintln(out, "2. Manual input via command line (separate multiple codes with commas, e.g., 0537,0100,0210)")
+
+	for {
+		fmt.Fprint(out, "Enter option (1/2): ")
+		if !scanner.Scan() {
+			return Config{}, errNoInput
+		}
+		choice := strings.TrimSpace(scanner.Text())
+		switch choice {
+		case "1":
+			config, err := loadMiddleCodesFromConfig(defaultMiddle)
+			if err != nil {
+				fmt.Fprintf(out, "Config file processing failed: %v\n", err)
+				continue
+			}
+			config.MiddleCodes = reviewMiddleCodes(scanner, out, config.MiddleCodes)
+			return config, nil
+		case "2":
+			middleCodes, err := inputMiddleCodes(scanner, out)
+			if errors.Is(err, errNoInput) {
+				return Config{}, err
+			}
+			if err != nil {
+				fmt.Fprintf(out, "Input error: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "Manual input successful, total %d %d-digit middle codes: %v\n", len(middleCodes), layout.middleLen, middleCodes)
+			return Config{MiddleCodes: middleCodes}, nil
+		default:
+			fmt.Fprintln(out, "Invalid option, please enter 1 or 2")
+		}
+	}
+}
+
+// 生成前增删从配置文件读取的中间码，只修改内存中的列表；新增的中间码与手动输入同样校验和去重，
+// 直接输入中间码（如0537,0100）等同于a命令。至少保留一个中间码，输入结束（EOF）等同于done
+func reviewMiddleCodes(scanner *bufio.Scanner, out io.Writer, codes []string) []string {
+	fromConfig := make(map[string]bool)
+	for _, code := range codes {
+		fromConfig[code] = true
+	}
+	codes = append([]string(nil), codes...)
+	add := func(input string) {
+		added, err := parseMiddleCodes(input)
+		if err != nil {
+			fmt.Fprintf(out, "Input error: %v\n", err)
+			return
+		}
+		merged, err := parseMiddleCodes(strings.Join(append(codes, added...), ","))
+		if err != nil {
+			fmt.Fprintf(out, "Input error: %v\n", err)
+			return
+		}
+		fmt.Fprintf(out, "Added %d middle codes\n", len(merged)-len(codes))
+		codes = merged
+	}
+	for {
+		fmt.Fprintf(out, "Middle codes (%d): %v\n", len(codes), codes)
+		fmt.Fprint(out, "Add more middle codes (comma-separated, e.g. 0537,0100), remove (r 0100), done (blank or d): ")
+		if !scanner.Scan() {
+			break
+		}
+		input := strings.TrimSpace(scanner.Text())
+		command, arg, _ := strings.Cut(input, " ")
+		arg = strings.TrimSpace(arg)
+		switch strings.ToLower(command) {
+		case "d", "":
+			reportReviewedCodes(out, codes, fromConfig)
+			return codes
+		case "a":
+			add(arg)
+		case "r":
+			var kept []string
+			for _, code := range codes {
+				if code != arg {
+					kept = append(kept, code)
+				}
+			}
+			switch {
+			case len(kept) == len(codes):
+				fmt.Fprintf(out, "Middle code %q is not in the list\n", arg)
+			case len(kept) == 0:
+				fmt.Fprintln(out, "Cannot remove the last middle code")
+			default:
+				codes = kept
+			}
+		default:
+			add(input)
+		}
+	}
+	reportReviewedCodes(out, codes, fromConfig)
+	return codes
+}
+
+// 报告合并后的中间码列表及其中来自配置文件和手动添加的数量
+func reportReviewedCodes(out io.Writer, codes []string, fromConfig map[string]bool) {
+	added := 0
+	for _, code := range codes {
+		if !fromConfig[code] {
+			added++
+		}
+	}
+	fmt.Fprintf(out, "Using %d middle codes (%d from %s, %d added manually): %v\n", len(codes), len(codes)-added, configFile, added, codes)
+}
+
+// 询问号码总数上限，相当于-limit；空行表示沿用命令行的设置
+func askLimit(scanner *bufio.Scanner, out io.Writer) (int64, error) {
+	for {
+		fmt.Fprint(out, "Limit total numbers? (blank for all): ")
+		if !scanner.Scan() {
+			return 0, errNoInput
+		}
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			return 0, nil
+		}
+		limit, err := strconv.ParseInt(input, 10, 64)
+		if err == nil && limit > 0 {
+			return limit, nil
+		}
+		fmt.Fprintln(out, "Invalid input, please enter a positive integer or leave blank")
+	}
+}
+
+// 询问是否继续运行，提取为独立函数；输入结束时按退出处理
+func askToContinue(scanner *bufio.Scanner, out io.Writer) bool {
+	for {
+		fmt.Fprint(out, "\nExit program? (y/n, 'n' to reselect middle code input method): ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return false
+		}
+		quitChoice := strings.TrimSpace(scanner.Text())
+		switch quitChoice {
+		case "y", "Y":
+			return false
+		case "n", "N":
+			return true
+		default:
+			fmt.Fprintln(out, "Invalid input, please enter y or n")
+		}
+	}
+}
+
+func inputMiddleCodes(scanner *bufio.Scanner, out io.Writer) ([]string, error) {
+	fmt.Fprintf(out, "Enter multiple %d-digit middle codes (separate with commas, e.g., 0537,0100,0210; wildcards like 05* or 0?37 and ranges like 0100-0120 are allowed): ", layout.middleLen)
+	if !scanner.Scan() {
+		return nil, errNoInput
+	}
+	return parseMiddleCodes(scanner.Text())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 按旧版参数解析出默认选项
+func legacyOptions(t *testing.T, args ...string) options {
+	t.Helper()
+	_, opts, err := parseArgs(args)
+	if err != nil {
+		t.Fatalf("parseArgs(%v) returned %v", args, err)
+	}
+	return opts
+}
+
+func TestRunInteractiveManualInput(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(envMiddleCodes, "")
+	var out bytes.Buffer
+	err := runInteractive(strings.NewReader("2\n0537\n\ny\n"), &out, initDefaultSegments(), legacyOptions(t))
+	if err != nil {
+		t.Fatalf("runInteractive returned %v", err)
+	}
+
+	for _, prompt := range []string{
+		"Please select 4-digit middle code input method:",
+		"Enter option (1/2): ",
+		"Enter multiple 4-digit middle codes",
+		"Manual input successful, total 1 4-digit middle codes: [0537]",
+		"Limit total numbers? (blank for all): ",
+		"✅ Phone numbers have been successfully exported",
+		"Exit program? (y/n",
+		"Exiting program...",
+	} {
+		if !strings.Contains(out.String(), prompt) {
+			t.Errorf("output is missing %q", prompt)
+		}
+	}
+
+	data, err := os.ReadFile("phonedict.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if want := len(initDefaultSegments().All()) * 10000; len(lines) != want {
+		t.Fatalf("got %d lines, want %d", len(lines), want)
+	}
+	if lines[0] != "13405370000" {
+		t.Fatalf("first line %q, want 13405370000", lines[0])
+	}
+}
+
+func TestSelectMiddleCodesRetriesInvalidOption(t *testing.T) {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(strings.NewReader("3\n2\n0537,0100-0101\n"))
+	config, err := selectMiddleCodes(scanner, &out, "")
+	if err != nil {
+		t.Fatalf("selectMiddleCodes returned %v", err)
+	}
+	if got := strings.Join(config.MiddleCodes, ","); got != "0537,0100,0101" {
+		t.Fatalf("got middle codes %s", got)
+	}
+	if !strings.Contains(out.String(), "Invalid option, please enter 1 or 2") {
+		t.Fatal("invalid option was not reported")
+	}
+}
+
+func TestAskLimit(t *testing.T) {
+	for _, tc := range []struct {
+		input   string
+		want    int64
+		retries int
+	}{
+		{"\n", 0, 0},
+		{"500\n", 500, 0},
+		{"abc\n-5\n20\n", 20, 2},
+	} {
+		var out bytes.Buffer
+		limit, err := askLimit(bufio.NewScanner(strings.NewReader(tc.input)), &out)
+		if err != nil {
+			t.Fatalf("askLimit(%q) returned %v", tc.input, err)
+		}
+		if limit != tc.want {
+			t.Errorf("askLimit(%q) = %d, want %d", tc.input, limit, tc.want)
+		}
+		if got := strings.Count(out.String(), "Invalid input"); got != tc.retries {
+			t.Errorf("askLimit(%q) reported %d invalid inputs, want %d", tc.input, got, tc.retries)
+		}
+	}
+}
+
+func TestSelectMiddleCodesMenuAutoCreate(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Cleanup(func() { autoCreateConfig = true })
+	for _, tc := range []struct {
+		autoCreate bool
+		want       string
+		unwanted   string
+	}{
+		{true, "will be auto-created", "must already exist"},
+		{false, "must already exist", "will be auto-created"},
+	} {
+		autoCreateConfig = tc.autoCreate
+		var out bytes.Buffer
+		selectMiddleCodes(bufio.NewScanner(strings.NewReader("")), &out, "")
+		if !strings.Contains(out.String(), tc.want) || strings.Contains(out.String(), tc.unwanted) {
+			t.Errorf("autoCreateConfig=%v menu:\n%s", tc.autoCreate, out.String())
+		}
+	}
+
+	// -no-auto-create时选择1不会创建配置文件
+	autoCreateConfig = false
+	var out bytes.Buffer
+	if _, err := selectMiddleCodes(bufio.NewScanner(strings.NewReader("1\n")), &out, ""); err != errNoInput {
+		t.Fatalf("got %v, want errNoInput after the missing config is reported", err)
+	}
+	if _, err := os.Stat(configFile); !os.IsNotExist(err) {
+		t.Fatalf("%s was created with -no-auto-create", configFile)
+	}
+	if !strings.Contains(out.String(), "-no-auto-create is set") {
+		t.Fatalf("missing config was not reported:\n%s", out.String())
+	}
+}
+
+// 输入在任一提示处结束时都正常退出，不会反复提示
+func TestRunInteractiveEOF(t *testing.T) {
+	for _, tc := range []struct {
+		name, input string
+		generated   bool
+	}{
+		{"menu", "", false},
+		{"invalid option", "3\n", false},
+		{"middle codes", "2\n", false},
+		{"limit", "2\n0537\n", false},
+		{"exit prompt", "2\n0537\n\n", true},
+		{"exit prompt after invalid answer", "2\n0537\n\nmaybe\n", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			t.Setenv(envMiddleCodes, "")
+			var out bytes.Buffer
+			var err error
+			captureStdout(t, func() {
+				err = runInteractive(strings.NewReader(tc.input), &out, initDefaultSegments(), legacyOptions(t))
+			})
+			if err != nil {
+				t.Fatalf("runInteractive returned %v", err)
+			}
+			if want := "xiting program...\n"; !strings.HasSuffix(out.String(), want) {
+				t.Fatalf("output does not end with an exit message:\n%s", out.String())
+			}
+			if n := strings.Count(out.String(), "Enter option (1/2): "); n > 2 {
+				t.Fatalf("the menu was shown %d times", n)
+			}
+			if _, err := os.Stat("phonedict.txt"); (err == nil) != tc.generated {
+				t.Fatalf("phonedict.txt exists: %v, want %v", err == nil, tc.generated)
+			}
+		})
+	}
+}
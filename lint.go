@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+)
+
+// -lint：只检查配置文件能否使用，不生成号码，也不会自动创建缺失的配置文件。
+// 无效的中间码、号段映射和分组只计为警告；文件缺失、无法解析或没有可用的中间码时返回错误
+func lintConfig(segments Segments, defaultMiddle string) error {
+	configPath := configFile
+	raw, err := readConfigFile(configPath)
+	var config Config
+	if err == nil {
+		config, err = loadMiddleCodesFromConfig(defaultMiddle)
+	}
+	if err != nil {
+		fmt.Fprintf(infoOut, "❌ Lint %s: FAIL\n", configPath)
+		return err
+	}
+	// config中的middleCodes可能已被回退值或分组的中间码替换，按原始内容统计
+	validMiddle := len(raw.MiddleCodes) - countInvalidCodes(raw.MiddleCodes)
+	validDefault := len(raw.DefaultMiddleCodes) - countInvalidCodes(raw.DefaultMiddleCodes)
+	validMaps := 0
+	for _, codes := range config.prefixMiddles(segments) {
+		if len(codes) > 0 {
+			validMaps++
+		}
+	}
+
+	warnings := len(raw.MiddleCodes) - validMiddle + len(raw.DefaultMiddleCodes) - validDefault +
+		len(raw.Campaigns) - len(config.Campaigns) + len(raw.PrefixMiddleMap) - validMaps
+	fmt.Fprintf(infoOut, "✅ Lint %s: PASS (%d warnings)\n", configPath, warnings)
+	fmt.Fprintf(infoOut, "middleCodes: %d valid, %d invalid | defaultMiddleCodes: %d valid, %d invalid\n",
+		validMiddle, len(raw.MiddleCodes)-validMiddle, validDefault, len(raw.DefaultMiddleCodes)-validDefault)
+	fmt.Fprintf(infoOut, "prefixMiddleMap: %d prefixes valid, %d skipped | campaigns: %d valid, %d skipped\n",
+		validMaps, len(raw.PrefixMiddleMap)-validMaps, len(config.Campaigns), len(raw.Campaigns)-len(config.Campaigns))
+	return nil
+}
+
+func countInvalidCodes(codes []string) int {
+	invalid := 0
+	for _, code := range codes {
+		if _, err := expandMiddleToken(code); err != nil {
+			invalid++
+		}
+	}
+	return invalid
+}
@@ -1,230 +1,1293 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
-var (
-	crawledMobile  []string // China Mobile prefixes
-	crawledUnicom  []string // China Unicom prefixes
-	crawledTelecom []string // China Telecom prefixes
+// Prefix 一个号段及其所属运营商（mobile/unicom/telecom）
+type Prefix struct {
+	Code     string
+	Operator string
+}
+
+// Segments 参与生成的号段，按移动、联通、电信的顺序排列，同一运营商内保持加入顺序。
+// 号段与运营商的对应只记录在这里，按运营商的号段列表和号段到运营商的映射都由它得出
+type Segments struct {
+	list []Prefix
+}
+
+const (
+	operatorMobile  = "mobile"
+	operatorUnicom  = "unicom"
+	operatorTelecom = "telecom"
 )
 
-type Config struct {
-	MiddleCodes []string `json:"middleCodes"`
+// 固定的运营商顺序，用于汇总输出
+var operatorOrder = []string{operatorMobile, operatorUnicom, operatorTelecom}
+
+// 运营商标签样式：code为简码，en为英文全称，cn为中文名称
+var operatorLabels = map[string]map[string]string{
+	"code": {operatorMobile: "CM", operatorUnicom: "CU", operatorTelecom: "CT"},
+	"en":   {operatorMobile: "China Mobile", operatorUnicom: "China Unicom", operatorTelecom: "China Telecom"},
+	"cn":   {operatorMobile: "中国移动", operatorUnicom: "中国联通", operatorTelecom: "中国电信"},
+}
+
+// 当前使用的标签样式，由-operator-label设置
+var operatorLabelStyle = "en"
+
+// 所有输出运营商名称的地方都通过此函数获取标签
+func operatorLabel(operator string) string {
+	if label, ok := operatorLabels[operatorLabelStyle][operator]; ok {
+		return label
+	}
+	return operator
+}
+
+// 号码各段的位数，默认3位号段+4位中间码+4位尾号
+type fieldLayout struct {
+	prefixLen int
+	middleLen int
+	suffixLen int
 }
 
+// 当前使用的号码布局，由-prefix-len/-middle-len/-suffix-len设置
+var layout = fieldLayout{prefixLen: 3, middleLen: 4, suffixLen: 4}
+
+func (l fieldLayout) totalLen() int {
+	return l.prefixLen + l.middleLen + l.suffixLen
+}
+
+func (l fieldLayout) middleRegex() *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^\d{%d}$`, l.middleLen))
+}
+
+// 检查号段数据与配置的号段位数是否一致
+func (l fieldLayout) checkPrefixes(segments Segments) error {
+	for _, seg := range segments.All() {
+		if len(seg) != l.prefixLen {
+			return fmt.Errorf("prefix %s has %d digits, but -prefix-len is %d", seg, len(seg), l.prefixLen)
+		}
+	}
+	return nil
+}
+
+// 退出码：0成功，1生成失败，2配置错误，3输入无效
 func main() {
-	initDefaultSegments()
-	fmt.Printf("Loaded built-in operator prefixes:\n")
-	fmt.Printf("China Mobile: %d | China Unicom: %d | China Telecom: %d\n",
-		len(crawledMobile), len(crawledUnicom), len(crawledTelecom))
-
-	scanner := bufio.NewScanner(os.Stdin)
-	// 使用循环代替递归调用main，避免栈溢出和资源泄漏
-	for {
-		middleCodes, err := selectMiddleCodes(scanner)
-		if err != nil {
-			fmt.Printf("Failed to get middle codes: %v\n", err)
-			continue
+	os.Exit(run())
+}
+
+// 执行程序并返回退出码，由main统一退出，保证defer的清理（如关闭日志文件）先执行
+func run() int {
+	cmd, opts, err := parseArgs(os.Args[1:])
+	if err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		// 参数错误已由flag包打印，可读模式下不再重复
+		if opts.jsonErrors {
+			jsonErrors = true
+			return fatal("invalid arguments", invalidInput(err))
+		}
+		return exitCodes[errorKindInput]
+	}
+	jsonErrors = opts.jsonErrors
+	if opts.emitRate != "" || opts.printConfig {
+		// 标准输出只留给号码或JSON
+		infoOut = os.Stderr
+	}
+	if opts.workdir != "" {
+		if err := enterWorkdir(opts.workdir); err != nil {
+			return fatal("Working directory", err)
 		}
+	}
+	configFile = resolveConfigFile(opts.configFile, opts.set["config"])
+	autoCreateConfig = !opts.noAutoCreate
+	strictMiddle = opts.strictMiddle
+	operatorLabelStyle = opts.operatorLabel
+	layout = opts.layout
+	if opts.deltaOut != "" {
+		// 增量号码写入-delta-out
+		opts.out = opts.deltaOut
+	}
+	if opts.suffixLens != "" {
+		// 号码长度按最长的尾号计算
+		_, layout.suffixLen, _ = parseSuffixLenRange(opts.suffixLens)
+	}
 
-		err = generatePhoneNumbers(middleCodes)
+	if opts.logFile != "" {
+		file, err := openRunLog(opts.logFile)
 		if err != nil {
-			fmt.Printf("Phone number generation failed: %v\n", err)
-		} else {
-			fmt.Println("\n✅ Phone numbers have been successfully exported to phonedict.txt")
+			return fatal("Log file", err)
 		}
+		defer file.Close()
+		writeLog("Started: %s", strings.Join(os.Args, " "))
+	}
 
-		// 询问是否退出
-		if !askToContinue(scanner) {
-			fmt.Println("Exiting program...")
-			return
+	// 只输出schema，便于重定向到文件
+	if opts.configSchema {
+		if err := printConfigSchema(); err != nil {
+			return fatal("Config schema", err)
 		}
-		fmt.Println("-------------------------- Restart --------------------------")
+		return 0
 	}
-}
 
-// 选择中间码输入方式，提取为独立函数
-func selectMiddleCodes(scanner *bufio.Scanner) ([]string, error) {
-	var middleCodes []string
-	fmt.Println("\nPlease select 4-digit middle code input method:")
-	fmt.Println("1. Read from config.json (file will be auto-created if it doesn't exist)")
-	fmt.Println("2. Manual input via command line (separate multiple codes with commas, e.g., 0537,0100,0210)")
+	segments := initDefaultSegments()
+	if opts.prefixFile != "" {
+		if segments, err = loadPrefixFile(opts.prefixFile, opts.mergePrefixes); err != nil {
+			return fatal("Prefix file", invalidInput(err))
+		}
+	}
+	if err := layout.checkPrefixes(segments); err != nil {
+		return fatal("Prefix data does not match the configured layout", invalidInput(err))
+	}
+	switch {
+	case opts.prefixFile == "":
+		fmt.Fprintf(infoOut, "Loaded built-in operator prefixes:\n")
+	case opts.mergePrefixes:
+		fmt.Fprintf(infoOut, "Loaded prefixes from %s, merged with the built-in ones:\n", opts.prefixFile)
+	default:
+		fmt.Fprintf(infoOut, "Loaded prefixes from %s (built-in prefixes replaced):\n", opts.prefixFile)
+	}
+	fmt.Fprintf(infoOut, "%s: %d | %s: %d | %s: %d\n",
+		operatorLabel(operatorMobile), len(segments.Prefixes(operatorMobile)),
+		operatorLabel(operatorUnicom), len(segments.Prefixes(operatorUnicom)),
+		operatorLabel(operatorTelecom), len(segments.Prefixes(operatorTelecom)))
 
-	for {
-		fmt.Print("Enter option (1/2): ")
-		scanner.Scan()
-		choice := strings.TrimSpace(scanner.Text())
-		switch choice {
-		case "1":
-			config, err := loadConfig()
-			if err != nil {
-				fmt.Printf("Config file processing failed: %v\n", err)
-				continue
-			}
-			middleCodes = config.MiddleCodes
-			if len(middleCodes) == 0 {
-				fmt.Println("Warning: middleCodes in config.json is empty, using default middle code [0537]")
-				middleCodes = []string{"0537"}
-			}
-			fmt.Printf("Successfully read %d 4-digit middle codes from config file: %v\n", len(middleCodes), middleCodes)
-			return middleCodes, nil
-		case "2":
-			middleCodes, err := inputMiddleCodes(scanner)
-			if err != nil {
-				fmt.Printf("Input error: %v\n", err)
-				continue
-			}
-			fmt.Printf("Manual input successful, total %d 4-digit middle codes: %v\n", len(middleCodes), middleCodes)
-			return middleCodes, nil
-		default:
-			fmt.Println("Invalid option, please enter 1 or 2")
+	operators, err := resolveOperators(opts)
+	if err != nil {
+		return fatal("Operator selection failed", err)
+	}
+	if len(operators) < len(operatorOrder) {
+		segments = segments.Only(operators)
+		fmt.Fprintf(infoOut, "Using operators: %v (%d prefixes)\n", operators, len(segments.All()))
+	}
+	if opts.prefixSample > 0 {
+		seed := randomSeed(opts)
+		if segments, err = segments.Sample(opts.prefixSample, seed); err != nil {
+			return fatal("Prefix sampling failed", invalidInput(err))
 		}
+		fmt.Fprintf(infoOut, "Randomly selected %d prefixes (-prefix-sample, seed %d): %s\n", opts.prefixSample, seed, strings.Join(segments.All(), ","))
 	}
-}
 
-// 询问是否继续运行，提取为独立函数
-func askToContinue(scanner *bufio.Scanner) bool {
-	for {
-		fmt.Print("\nExit program? (y/n, 'n' to reselect middle code input method): ")
-		scanner.Scan()
-		quitChoice := strings.TrimSpace(scanner.Text())
-		switch quitChoice {
-		case "y", "Y":
-			return false
-		case "n", "N":
-			return true
-		default:
-			fmt.Println("Invalid input, please enter y or n")
+	if cmd != nil {
+		if err := cmd.run(segments, opts); err != nil {
+			return fatal(cmd.name+" failed", err)
 		}
+		return 0
+	}
+
+	if opts.listPrefixes {
+		printPrefixTable(segments)
+		return 0
 	}
-}
 
-func loadConfig() (Config, error) {
-	var config Config
-	configPath := "config.json"
+	if opts.selfTest {
+		if err := runSelfTest(); err != nil {
+			fmt.Fprintln(infoOut, "Self-test: FAIL")
+			return fatal("Self-test failed", err)
+		}
+		fmt.Fprintf(infoOut, "Self-test: PASS (%d numbers written to a temp file and read back)\n", selfTestSuffixes)
+		return 0
+	}
 
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		fmt.Printf("%s not found, creating automatically...\n", configPath)
-		defaultConfig := Config{
-			MiddleCodes: []string{"0537", "0100", "0210", "0755"},
+	if opts.lint {
+		// 号段映射按全部内置号段检查，不受-operators影响
+		if err := lintConfig(initDefaultSegments(), opts.defaultMiddle); err != nil {
+			return fatal("Config lint failed", err)
 		}
-		jsonData, err := json.MarshalIndent(defaultConfig, "", "  ")
+		return 0
+	}
+
+	if opts.analyzeMiddle {
+		config, err := legacyMiddleCodes(opts)
 		if err != nil {
-			return config, fmt.Errorf("failed to generate default config: %v", err)
+			return fatal("Middle code analysis failed", err)
 		}
-		if err := os.WriteFile(configPath, jsonData, 0644); err != nil {
-			return config, fmt.Errorf("failed to create %s: %v", configPath, err)
+		analyzeMiddleCodes(config.MiddleCodes)
+		return 0
+	}
+
+	if opts.serve != "" {
+		if err := serveHTTP(opts.serve, segments); err != nil {
+			return fatal("HTTP server failed", err)
 		}
-		fmt.Printf("✅ %s created successfully, sample middle codes: %v\n", configPath, defaultConfig.MiddleCodes)
-		fmt.Println("Note: You can edit this file directly to modify the middleCodes list (must be 4-digit numbers)")
-		return defaultConfig, nil
-	} else if err != nil {
-		return config, fmt.Errorf("failed to check %s status: %v", configPath, err)
+		return 0
 	}
 
-	file, err := os.Open(configPath)
-	if err != nil {
-		return config, fmt.Errorf("failed to open %s: %v", configPath, err)
+	if opts.sortDedup != "" {
+		if err := sortDedupFile(opts.sortDedup, opts.chunkLines); err != nil {
+			return fatal("Sort and dedup failed", err)
+		}
+		return 0
 	}
-	defer file.Close()
 
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&config); err != nil {
-		return config, fmt.Errorf("failed to parse %s format (check commas and quotes): %v", configPath, err)
+	if opts.validateFile != "" {
+		config, err := legacyMiddleCodes(opts)
+		if err != nil {
+			return fatal("Config file processing failed", err)
+		}
+		if err := validateNumbersFile(opts.validateFile, segments, config, opts.matchedOut); err != nil {
+			return fatal("Validation failed", err)
+		}
+		return 0
 	}
 
-	validRegex := regexp.MustCompile(`^\d{4}$`)
-	validCodes := []string{}
-	for _, code := range config.MiddleCodes {
-		if validRegex.MatchString(code) {
-			validCodes = append(validCodes, code)
-		} else {
-			fmt.Printf("Warning: Invalid middle code %s in %s (must be 4-digit number), skipped\n", code, configPath)
+	if opts.printConfig {
+		config, err := legacyMiddleCodes(opts)
+		if err == nil {
+			err = printEffectiveConfig(segments, config, opts)
+		}
+		if err != nil {
+			return fatal("Print config failed", err)
+		}
+		return 0
+	}
+
+	if opts.preview {
+		config, err := legacyMiddleCodes(opts)
+		if err == nil {
+			err = printPreview(segments, config, opts)
+		}
+		if err != nil {
+			return fatal("Preview failed", err)
+		}
+		return 0
+	}
+
+	if opts.diff != "" {
+		config, err := legacyMiddleCodes(opts)
+		if err == nil {
+			err = printDiff(segments, config, opts)
+		}
+		if err != nil {
+			return fatal("Diff failed", err)
+		}
+		return 0
+	}
+
+	if opts.emitRate != "" {
+		config, err := legacyMiddleCodes(opts)
+		if err == nil {
+			err = emitNumbers(segments, config, opts)
+		}
+		if err != nil {
+			return fatal("Emit failed", err)
+		}
+		return 0
+	}
+
+	if opts.densityReport != "" {
+		config, err := legacyMiddleCodes(opts)
+		if err == nil {
+			err = writeDensityReport(segments, config, opts)
+		}
+		if err != nil {
+			return fatal("Density report failed", err)
 		}
+		return 0
 	}
-	config.MiddleCodes = validCodes
 
-	return config, nil
+	// 在开始交互之前检查输出目录是否可写，避免输入完所有参数后才失败
+	for _, path := range outputPaths(opts) {
+		if err := checkOutputDir(path); err != nil {
+			return fatal("Output directory check failed", err)
+		}
+	}
+
+	// 交互模式的错误已在菜单中显示，这里只决定退出码
+	if err := runInteractive(os.Stdin, infoOut, segments, opts); err != nil {
+		return exitCodes[errorKind(err)]
+	}
+	return 0
+}
+
+// 不带子命令时的中间码来源：NG_MIDDLE_CODES > config.json
+func legacyMiddleCodes(opts options) (Config, error) {
+	middleCodes, err := middleCodesFromEnv()
+	if err != nil || middleCodes != nil {
+		return Config{MiddleCodes: middleCodes}, err
+	}
+	return loadMiddleCodesFromConfig(opts.defaultMiddle)
 }
 
-func inputMiddleCodes(scanner *bufio.Scanner) ([]string, error) {
-	fmt.Print("Enter multiple 4-digit middle codes (separate with commas, e.g., 0537,0100,0210): ")
-	scanner.Scan()
-	input := strings.TrimSpace(scanner.Text())
+// 解析逗号分隔的中间码，支持通配符（如05*、0?37）和范围（如0100-0120），去重并丢弃不合法的普通中间码；
+// 通配符或范围写错时返回错误，-strict-middle时不合法的普通中间码也会全部列出后报错（空项除外）
+func parseMiddleCodes(input string) ([]string, error) {
+	input = strings.TrimSpace(input)
 	if input == "" {
 		return nil, fmt.Errorf("input cannot be empty")
 	}
 
-	rawCodes := strings.Split(input, ",")
-	var validCodes []string
-	validRegex := regexp.MustCompile(`^\d{4}$`)
+	var validCodes, invalid []string
 	seen := make(map[string]bool)
-
-	for _, code := range rawCodes {
-		code = strings.TrimSpace(code)
-		if validRegex.MatchString(code) && !seen[code] {
-			validCodes = append(validCodes, code)
-			seen[code] = true
+	for _, token := range strings.Split(input, ",") {
+		expanded, err := expandMiddleToken(token)
+		if errors.Is(err, errInvalidMiddleCode) {
+			if strings.TrimSpace(token) != "" {
+				invalid = append(invalid, strings.TrimSpace(token))
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, code := range expanded {
+			if !seen[code] {
+				validCodes = append(validCodes, code)
+				seen[code] = true
+			}
 		}
 	}
 
+	if strictMiddle && len(invalid) > 0 {
+		return nil, fmt.Errorf("%d invalid middle codes (-strict-middle, must be %d-digit numbers): %s", len(invalid), layout.middleLen, strings.Join(invalid, ", "))
+	}
 	if len(validCodes) == 0 {
-		return nil, fmt.Errorf("no valid middle codes detected (must enter 4-digit numbers separated by commas)")
+		return nil, fmt.Errorf("no valid middle codes detected (must enter %d-digit numbers separated by commas)", layout.middleLen)
 	}
 
 	return validCodes, nil
 }
 
-func initDefaultSegments() {
-	crawledMobile = []string{"134", "135", "136", "137", "138", "139", "147", "150", "151", "152", "157", "158", "159", "178", "182", "183", "184", "187", "188", "198"}
-	crawledUnicom = []string{"130", "131", "132", "145", "155", "156", "166", "175", "176", "185", "186"}
-	crawledTelecom = []string{"133", "149", "153", "173", "177", "180", "181", "189", "199"}
+// 普通中间码位数或字符不对，调用方可以选择跳过而不是报错
+var errInvalidMiddleCode = errors.New("invalid middle code")
+
+// 展开一个中间码输入项：普通中间码原样返回，通配符（05*、0?37）和范围（0100-0120，含两端）
+// 按数字从小到大展开；所有输入方式（-middle、交互输入、配置文件）都通过它解析
+func expandMiddleToken(token string) ([]string, error) {
+	token = strings.TrimSpace(token)
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("%w: empty", errInvalidMiddleCode)
+	case strings.ContainsAny(token, "*?"):
+		expanded := expandWildcard(token, layout.middleLen)
+		if len(expanded) == 0 {
+			return nil, fmt.Errorf("pattern %s matches no %d-digit middle codes", token, layout.middleLen)
+		}
+		return expanded, nil
+	case strings.Contains(token, "-"):
+		first, last, _ := strings.Cut(token, "-")
+		first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+		validRegex := layout.middleRegex()
+		if !validRegex.MatchString(first) || !validRegex.MatchString(last) || first > last {
+			return nil, fmt.Errorf("invalid range %s (must be two %d-digit middle codes, the first not greater than the second)", token, layout.middleLen)
+		}
+		start, _ := strconv.Atoi(first)
+		end, _ := strconv.Atoi(last)
+		codes := make([]string, 0, end-start+1)
+		for n := start; n <= end; n++ {
+			codes = append(codes, fmt.Sprintf("%0*d", layout.middleLen, n))
+		}
+		return codes, nil
+	case !layout.middleRegex().MatchString(token):
+		return nil, fmt.Errorf("%w %s (must be %d-digit number)", errInvalidMiddleCode, token, layout.middleLen)
+	}
+	return []string{token}, nil
 }
 
-func generatePhoneNumbers(middleCodes []string) error {
-	file, err := os.Create("phonedict.txt")
-	if err != nil {
-		return fmt.Errorf("failed to create file: %v", err)
+// 展开中间码通配符：?匹配一位数字，末尾的*匹配剩余的所有位，
+// 结果按数字从小到大排列，模式不合法或长度不符时返回nil
+func expandWildcard(pattern string, length int) []string {
+	if i := strings.IndexByte(pattern, '*'); i >= 0 {
+		if i != len(pattern)-1 || len(pattern)-1 > length {
+			return nil
+		}
+		pattern = pattern[:i] + strings.Repeat("?", length-i)
+	}
+	if len(pattern) != length {
+		return nil
 	}
-	defer file.Close() // 确保文件在函数退出时关闭
-	writer := bufio.NewWriter(file)
-	defer writer.Flush() // 确保缓冲区数据写入文件
+	codes := []string{""}
+	for _, ch := range pattern {
+		var next []string
+		switch {
+		case ch == '?':
+			for _, code := range codes {
+				for d := '0'; d <= '9'; d++ {
+					next = append(next, code+string(d))
+				}
+			}
+		case ch >= '0' && ch <= '9':
+			for _, code := range codes {
+				next = append(next, code+string(ch))
+			}
+		default:
+			return nil
+		}
+		codes = next
+	}
+	return codes
+}
+
+// 内置号段表，程序启动时按运营商顺序构建一次
+var defaultPrefixes = newSegments(map[string][]string{
+	operatorMobile:  {"134", "135", "136", "137", "138", "139", "147", "150", "151", "152", "157", "158", "159", "178", "182", "183", "184", "187", "188", "198"},
+	operatorUnicom:  {"130", "131", "132", "145", "155", "156", "166", "175", "176", "185", "186"},
+	operatorTelecom: {"133", "149", "153", "173", "177", "180", "181", "189", "199"},
+}).list
+
+// 按运营商顺序把各运营商的号段合成一个列表
+func newSegments(byOperator map[string][]string) Segments {
+	var s Segments
+	for _, operator := range operatorOrder {
+		for _, code := range byOperator[operator] {
+			s.list = append(s.list, Prefix{Code: code, Operator: operator})
+		}
+	}
+	return s
+}
+
+// 返回内置号段，每次调用都复制一份，调用方可随意修改
+func initDefaultSegments() Segments {
+	return Segments{list: slices.Clone(defaultPrefixes)}
+}
 
-	allSegments := append(append(crawledMobile, crawledUnicom...), crawledTelecom...)
-	totalSegments := len(allSegments)
+// Prefixes 返回指定运营商的号段，未知运营商返回nil
+func (s Segments) Prefixes(operator string) []string {
+	var prefixes []string
+	for _, p := range s.list {
+		if p.Operator == operator {
+			prefixes = append(prefixes, p.Code)
+		}
+	}
+	return prefixes
+}
+
+// 全部号段，顺序为移动、联通、电信
+func (s Segments) All() []string {
+	all := make([]string, len(s.list))
+	for i, p := range s.list {
+		all[i] = p.Code
+	}
+	return all
+}
+
+// 只保留指定运营商的号段
+func (s Segments) Only(operators []string) Segments {
+	var only Segments
+	for _, p := range s.list {
+		if slices.Contains(operators, p.Operator) {
+			only.list = append(only.list, p)
+		}
+	}
+	return only
+}
+
+// 随机抽取k个号段，各运营商按号段数量的比例分配名额（最大余数法），抽中的号段保持原有顺序，
+// 相同seed得到相同结果
+func (s Segments) Sample(k int, seed int64) (Segments, error) {
+	total := len(s.All())
+	if k > total {
+		return s, fmt.Errorf("-prefix-sample %d exceeds the %d available prefixes", k, total)
+	}
+	type remainder struct {
+		operator string
+		frac     float64
+	}
+	quotas := make(map[string]int)
+	var remainders []remainder
+	assigned := 0
+	for _, operator := range operatorOrder {
+		exact := float64(k*len(s.Prefixes(operator))) / float64(total)
+		quotas[operator] = int(exact)
+		assigned += quotas[operator]
+		remainders = append(remainders, remainder{operator, exact - float64(quotas[operator])})
+	}
+	sort.SliceStable(remainders, func(i, j int) bool { return remainders[i].frac > remainders[j].frac })
+	for i := 0; assigned < k; i++ {
+		quotas[remainders[i].operator]++
+		assigned++
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(seed), 0))
+	pick := func(prefixes []string, n int) []string {
+		chosen := rng.Perm(len(prefixes))[:n]
+		sort.Ints(chosen)
+		picked := make([]string, 0, n)
+		for _, i := range chosen {
+			picked = append(picked, prefixes[i])
+		}
+		return picked
+	}
+	picked := make(map[string][]string)
+	for _, operator := range operatorOrder {
+		picked[operator] = pick(s.Prefixes(operator), quotas[operator])
+	}
+	return newSegments(picked), nil
+}
+
+// 解析逗号分隔的运营商名称（mobile/unicom/telecom），去重并保持输入顺序
+func parseOperators(input string) ([]string, error) {
+	var operators []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(input, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := operatorLabels["en"][name]; !ok {
+			return nil, fmt.Errorf("unknown operator %q (must be mobile, unicom or telecom)", name)
+		}
+		operators = append(operators, name)
+		seen[name] = true
+	}
+	if len(operators) == 0 {
+		return nil, fmt.Errorf("no operators given")
+	}
+	return operators, nil
+}
+
+// 按运营商分组打印号段表，每行10个号段
+func printPrefixTable(segments Segments) {
+	fmt.Fprintf(infoOut, "\n%-16s %-6s %s\n", "Operator", "Count", "Prefixes")
+	for _, operator := range operatorOrder {
+		prefixes := segments.Prefixes(operator)
+		for i := 0; i < len(prefixes) || i == 0; i += 10 {
+			end := min(i+10, len(prefixes))
+			if i == 0 {
+				fmt.Fprintf(infoOut, "%-16s %-6d %s\n", operatorLabel(operator), len(prefixes), strings.Join(prefixes[i:end], " "))
+			} else {
+				fmt.Fprintf(infoOut, "%-16s %-6s %s\n", "", "", strings.Join(prefixes[i:end], " "))
+			}
+		}
+	}
+	fmt.Fprintf(infoOut, "%-16s %d\n", "Total", len(segments.All()))
+}
+
+// 号段到运营商的映射
+func (s Segments) Operators() map[string]string {
+	operators := make(map[string]string, len(s.list))
+	for _, p := range s.list {
+		operators[p.Code] = p.Operator
+	}
+	return operators
+}
+
+// 打印生成计划并返回预计号码数量和输出字节数，allowed为按号段限制的中间码（可为nil）
+func printGenerationPlan(prefixes, middleCodes []string, allowed map[string][]string, blocks []Block, opts options, formats []string) (int64, uint64) {
+	totalSegments := len(prefixes)
 	totalMiddle := len(middleCodes)
-	totalNumbers := totalSegments * totalMiddle * 10000
-
-	fmt.Printf("\n📱 Starting phone number generation:\n")
-	fmt.Printf("Total prefixes: %d | Total middle codes: %d | Suffix range per combination: 0000-9999\n",
-		totalSegments, totalMiddle)
-	fmt.Printf("Estimated total numbers to generate: %d\n", totalNumbers)
-
-	generatedCount := 0
-	for _, seg := range allSegments {
-		for _, middle := range middleCodes {
-			for suffix := 0; suffix < 10000; suffix++ {
-				suffixStr := fmt.Sprintf("%04d", suffix)
-				phone := seg + middle + suffixStr
-				if _, err := writer.WriteString(phone + "\n"); err != nil {
-					return fmt.Errorf("failed to write to file: %v", err)
-				}
-				generatedCount++
-				if generatedCount%10000 == 0 {
-					writer.Flush()
-					fmt.Printf("Generated: %d / %d\n", generatedCount, totalNumbers)
+	start, end, step := suffixBounds(opts)
+	suffixCount := EstimateCount([]string{""}, []string{""}, start, end, step)
+	digits, _ := parseSuffixDigits(opts.suffixDigits)
+	if digits != "" {
+		suffixCount = int64(newDigitSuffixes(digits, layout.suffixLen).Len())
+	}
+	if opts.suffixLens != "" {
+		// 各位数的尾号空间相加
+		suffixCount = sweepSuffixCount(opts)
+	}
+	shardCount := suffixCount
+	if opts.shard != "" {
+		r, m, _ := parseShard(opts.shard)
+		shardStart, shardEnd, shardStep := shardSuffixes(start, end, step, r, m)
+		shardCount = EstimateCount([]string{""}, []string{""}, shardStart, shardEnd, shardStep)
+	}
+	perPair := shardCount
+	if opts.perPair > 0 && int64(opts.perPair) < shardCount {
+		perPair = int64(opts.perPair)
+	}
+	combinations := 0
+	for _, prefix := range prefixes {
+		if codes, ok := allowed[prefix]; ok {
+			combinations += len(codes)
+		} else {
+			combinations += totalMiddle
+		}
+	}
+	totalNumbers := int64(combinations) * perPair
+
+	fmt.Fprintf(infoOut, "\n📱 Phone number generation plan:\n")
+	if digits != "" {
+		fmt.Fprintf(infoOut, "Total prefixes: %d | Total middle codes: %d | Suffix digits per position: %s", totalSegments, totalMiddle, digits)
+	} else if opts.suffixLens != "" {
+		fmt.Fprintf(infoOut, "Total prefixes: %d | Total middle codes: %d | Suffix lengths per combination: %s", totalSegments, totalMiddle, opts.suffixLens)
+	} else {
+		fmt.Fprintf(infoOut, "Total prefixes: %d | Total middle codes: %d | Suffix range per combination: %0*d-%0*d",
+			totalSegments, totalMiddle, layout.suffixLen, start, layout.suffixLen, end)
+	}
+	if step > 1 {
+		fmt.Fprintf(infoOut, " step %d", step)
+	}
+	fmt.Fprintf(infoOut, " (%d suffixes)\n", suffixCount)
+	if opts.shard != "" {
+		fmt.Fprintf(infoOut, "Shard %s: %d suffixes per combination\n", opts.shard, shardCount)
+	}
+	if perPair < shardCount {
+		fmt.Fprintf(infoOut, "Suffixes per prefix/middle combination (-per-pair): %d\n", perPair)
+	}
+	if allowed != nil {
+		fmt.Fprintf(infoOut, "Prefix/middle combinations after prefixMiddleMap: %d (of %d)\n", combinations, totalSegments*totalMiddle)
+	}
+	if opts.suffixRanges != nil {
+		fmt.Fprintf(infoOut, "Prefixes with their own suffix range (operatorSuffixRanges): %d of %d\n", len(opts.suffixRanges), totalSegments)
+	}
+	if blocks != nil {
+		// 分配块使每个组合的尾号数不同，按实际组合空间逐个号段统计
+		perPrefix := spaceCountByPrefix(prefixes, middleCodes, spaceOptions(opts, allowed, blocks))
+		fullPerPrefix := spaceCountByPrefix(prefixes, middleCodes, spaceOptions(opts, allowed, nil))
+		printBlockCoverage(prefixes, perPrefix, fullPerPrefix)
+		totalNumbers = 0
+		for _, n := range perPrefix {
+			totalNumbers += n
+		}
+	} else if opts.suffixRanges != nil {
+		// 各运营商的尾号数不同，同样按实际组合空间统计
+		totalNumbers = 0
+		for _, n := range spaceCountByPrefix(prefixes, middleCodes, spaceOptions(opts, allowed, nil)) {
+			totalNumbers += n
+		}
+	}
+	if opts.sample > 0 && opts.sample < totalNumbers {
+		fmt.Fprintf(infoOut, "Combination space: %d | Random sample size: %d\n", totalNumbers, opts.sample)
+		totalNumbers = opts.sample
+	}
+	if opts.limit > 0 && opts.limit < totalNumbers {
+		fmt.Fprintf(infoOut, "Limited to the first %d numbers (-limit)\n", opts.limit)
+		totalNumbers = opts.limit
+	}
+	logf("Estimated total numbers to generate: %d\n", totalNumbers)
+	bytesPerNumber := 0
+	for _, format := range formats {
+		bytesPerNumber += formatRecordSize(format)
+	}
+	estimatedSize := uint64(totalNumbers) * uint64(bytesPerNumber)
+	if opts.compress != "" && opts.compress != "none" {
+		// 压缩输出按样本的实际字节数和压缩率估算，可用空间检查使用压缩后的大小
+		sampleOpts := append(spaceOptions(opts, allowed, blocks), formatOptions(opts)...)
+		if opts.sample > 0 || wantsShuffle(opts) {
+			sampleOpts = append(sampleOpts, WithShuffle(1))
+		}
+		if opts.set["output-base"] && opts.outputBase != 10 {
+			sampleOpts = append(sampleOpts, WithOutputBase(opts.outputBase))
+		}
+		var rawSize, compressedSize float64
+		for _, format := range formats {
+			bytesPerNumber, ratio := sampleCompression(prefixes, middleCodes, format, opts.compress, sampleOpts)
+			rawSize += float64(totalNumbers) * bytesPerNumber
+			compressedSize += float64(totalNumbers) * bytesPerNumber * ratio
+		}
+		fmt.Fprintf(infoOut, "Estimated output size: %.2f MB uncompressed, %.2f MB with %s (ratio sampled from %d numbers)\n",
+			rawSize/1024/1024, compressedSize/1024/1024, opts.compress, compressionSampleSize)
+		return totalNumbers, uint64(compressedSize)
+	}
+	fmt.Fprintf(infoOut, "Estimated output size: %.2f MB\n", float64(estimatedSize)/1024/1024)
+	return totalNumbers, estimatedSize
+}
+
+// 所有输出先写入临时文件，全部成功后再重命名为正式文件，保证输出要么完整要么不存在
+func generatePhoneNumbers(segments Segments, config Config, opts options) (generatedCount int64, err error) {
+	formats, err := parseFormats(opts.format)
+	if err != nil {
+		return 0, err
+	}
+	prefixes := segments.All()
+	middleCodes := config.MiddleCodes
+	allowed := config.prefixMiddles(segments)
+	if err := checkSuffixRangesOptions(config, formats, opts); err != nil {
+		return 0, invalidInput(err)
+	}
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	if formats[0] == "mask" {
+		return generateMasks(prefixes, middleCodes, allowed, opts)
+	}
+	if formats[0] == "ranges" {
+		return generateRanges(prefixes, middleCodes, allowed, opts)
+	}
+	if opts.e164 {
+		if err := checkE164Prefixes(prefixes); err != nil {
+			return 0, invalidInput(err)
+		}
+		if layout.totalLen() != 11 {
+			logf("⚠️ -e164 assumes 11-digit Chinese mobile numbers, these have %d digits\n", layout.totalLen())
+		}
+	}
+	if opts.shuffleSuffix {
+		opts.suffixSeed = randomSeed(opts)
+		logf("Suffix shuffle seed: %d (pass -seed=%d to reproduce this order)\n", opts.suffixSeed, opts.suffixSeed)
+	}
+	ctx := context.Background()
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	var pauser *Pauser
+	var stop context.CancelFunc
+	if opts.keys != nil {
+		ctx, stop = context.WithCancel(ctx)
+		defer stop()
+		pauser = &Pauser{}
+	}
+	var blocks []Block
+	if opts.blockFile != "" {
+		if blocks, err = loadBlockFile(opts.blockFile, segments, middleCodes); err != nil {
+			return 0, err
+		}
+		logf("Loaded %d allocation blocks from %s\n", len(blocks), opts.blockFile)
+		if blocks == nil {
+			blocks = []Block{}
+		}
+	}
+	var seeds []string
+	if opts.seedFile != "" {
+		if seeds, err = loadSeedNumbers(opts.seedFile); err != nil {
+			return 0, err
+		}
+		logf("Loaded %d seed numbers from %s, they are written first\n", len(seeds), opts.seedFile)
+	}
+	totalNumbers, estimatedSize := printGenerationPlan(prefixes, middleCodes, allowed, blocks, opts, formats)
+	paths := outputPaths(opts)
+	for _, path := range paths {
+		// -out-parallel的各部分大小相近，每个目录只需容纳自己的一份
+		if isRemoteOutput(path) {
+			continue
+		}
+		if err := checkDiskSpace(filepath.Dir(path), estimatedSize/uint64(len(paths)), opts.force); err != nil {
+			return 0, err
+		}
+	}
+	if opts.autoTune {
+		if !parallelWritable(formats, opts) {
+			logf("Notice: -auto-tune skipped, this run is written by a single goroutine (see -workers)\n")
+		} else if opts.workers, opts.batchSize, err = autoTune(prefixes, middleCodes,
+			append(spaceOptions(opts, allowed, blocks), formatOptions(opts)...), paths[0]); err != nil {
+			return 0, err
+		}
+	}
+	var parts [][]string
+	if opts.outParallel != "" {
+		if parts, err = splitPrefixes(prefixes, middleCodes, len(paths), spaceOptions(opts, allowed, blocks)); err != nil {
+			return 0, invalidInput(err)
+		}
+	}
+
+	var files []*outputFile
+	defer func() {
+		if err != nil {
+			for _, f := range files {
+				f.abort()
+			}
+		}
+	}()
+	var archive *zipArchive
+	if opts.zip != "" {
+		if archive, err = createZip(opts.zip, opts.writeRetries); err != nil {
+			return 0, err
+		}
+		defer func() {
+			if err != nil {
+				archive.abort()
+			}
+		}()
+	}
+	operators := segments.Operators()
+	var outputs []Output
+	header := ""
+	for i, t := range outputTargets(opts, formats) {
+		format := t.format
+		var f *outputFile
+		if archive != nil {
+			f, err = createZipEntry(archive, filepath.Base(t.path), format)
+		} else if opts.append {
+			f, err = appendOutput(t.path, format, opts.writeRetries)
+		} else {
+			f, err = createOutput(t.path, format, opts.compress, opts.writeRetries)
+		}
+		if err != nil {
+			return 0, err
+		}
+		files = append(files, f)
+		first := formatColumns(format)
+		if opts.index && first != "" {
+			first = "index," + first
+		}
+		if parts != nil && i > 0 {
+			// 列标题只写在第一个部分，按顺序拼接后与单个文件相同
+			first = ""
+		}
+		if format == "txt" && opts.header {
+			// 注释行不计入号码数量
+			header = headerLine(segments, totalNumbers+int64(len(seeds)))
+			first = header
+		}
+		if f.appended {
+			// 追加到已有文件时列标题已经存在
+			first = ""
+		}
+		if first != "" {
+			if _, err := io.WriteString(f.w, first+"\n"); err != nil {
+				return 0, fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+		encode := formatEncoder(format, operators)
+		if opts.index {
+			encode = indexedEncoder(format, encode, opts.indexDelim)
+		}
+		outputs = append(outputs, Output{W: f.w, Encode: encode, Binary: format == "binary"})
+	}
+	if err := writeSeedNumbers(outputs, seeds, opts.outputBase, opts.indexStart); err != nil {
+		return 0, err
+	}
+
+	middleCounts := make(map[string]int64)
+	prefixCounts := make(map[string]int64)
+	// 终端中原地刷新进度，否则逐行输出
+	var progress *progressLine
+	if f := infoFile(); f != nil && isTerminal(f) {
+		progress = newProgressLine()
+	}
+	reportProgress := func(done, total int64) {
+		if progress != nil {
+			progress.update(done, total)
+			writeLog("Generated: %d / %d", done, total)
+			return
+		}
+		logf("Generated: %d / %d\n", done, total)
+	}
+	var dog *watchdog
+	genOpts := append(spaceOptions(opts, allowed, blocks),
+		withObserver(func(prefix, middle string) {
+			middleCounts[middle]++
+			prefixCounts[prefix]++
+			if dog != nil {
+				dog.add()
+			}
+		}),
+		WithProgress(reportProgress),
+	)
+	genOpts = append(genOpts, formatOptions(opts)...)
+	var stats *numberStats
+	if opts.stats || opts.statsJSON != "" {
+		stats = &numberStats{}
+		genOpts = append(genOpts, withNumberObserver(stats.add))
+	}
+	// 已知号码占用最前面的序号
+	genOpts = append(genOpts, WithIndexStart(opts.indexStart+int64(len(seeds))))
+	genOpts = append(genOpts, WithContext(ctx))
+	if pauser != nil {
+		genOpts = append(genOpts, WithPause(pauser))
+	}
+	if opts.limit > 0 {
+		genOpts = append(genOpts, WithLimit(opts.limit))
+	}
+	if opts.noTrailingNL {
+		genOpts = append(genOpts, WithoutTrailingNewline())
+	}
+	if opts.maxMemory != "" {
+		maxMemory, _ := parseByteSize(opts.maxMemory)
+		genOpts = append(genOpts, WithMaxMemory(maxMemory))
+	}
+	if opts.grouped {
+		genOpts = append(genOpts, WithGroupHeaders(groupHeaderLine(config.Regions)))
+	}
+	if opts.set["output-base"] && opts.outputBase != 10 {
+		logf("⚠️ Numbers are written in base %d, this output is for specialized use and is not a phone number dictionary\n", opts.outputBase)
+		genOpts = append(genOpts, WithOutputBase(opts.outputBase))
+	}
+	if opts.interleave {
+		genOpts = append(genOpts, WithInterleave(operators))
+	}
+	// 过滤条件在-workers下会被并发调用，计数使用原子操作
+	var checksumPassed atomic.Int64
+	if opts.checksum != "" {
+		valid, _ := parseChecksum(opts.checksum)
+		genOpts = append(genOpts, WithFilter(countPassed(valid, &checksumPassed)))
+	}
+	var blocked atomic.Int64
+	if opts.blocklist != "" {
+		re, _ := parseBlocklist(opts.blocklist)
+		genOpts = append(genOpts, WithFilter(countRejected(blocklistFilter(re), &blocked)))
+	}
+	var duplicates atomic.Int64
+	if opts.dedupAgainst != "" {
+		stop := startHeartbeat("Loading " + opts.dedupAgainst)
+		existing, count, err := loadNumberSet(opts.dedupAgainst, opts.dedupMode, opts.dedupFPRate)
+		stop()
+		if err != nil {
+			return 0, err
+		}
+		logf("Loaded %d existing numbers from %s for dedup (%s mode)\n", count, opts.dedupAgainst, opts.dedupMode)
+		genOpts = append(genOpts, WithFilter(countRejected(excludeSet(existing), &duplicates)))
+	}
+	var unchanged atomic.Int64
+	if opts.deltaAgainst != "" {
+		stop := startHeartbeat("Loading " + opts.deltaAgainst)
+		old, count, err := loadNumberSet(opts.deltaAgainst, opts.dedupMode, opts.dedupFPRate)
+		stop()
+		if err != nil {
+			return 0, err
+		}
+		logf("Loaded %d numbers from %s, only new numbers are written (%s mode)\n", count, opts.deltaAgainst, opts.dedupMode)
+		genOpts = append(genOpts, WithFilter(countRejected(excludeSet(old), &unchanged)))
+	}
+	var unassigned, assigned atomic.Int64
+	if opts.complement != "" {
+		stop := startHeartbeat("Loading " + opts.complement)
+		set, count, skipped, err := loadAssignedNumbers(opts.complement, opts.dedupMode, opts.dedupFPRate)
+		stop()
+		if err != nil {
+			return 0, err
+		}
+		logf("Loaded %d assigned numbers from %s for -complement (%s mode, %d rows without a number skipped)\n", count, opts.complement, opts.dedupMode, skipped)
+		genOpts = append(genOpts, WithFilter(countPassed(countRejected(excludeSet(set), &assigned), &unassigned)))
+	}
+	var seedDuplicates atomic.Int64
+	if len(seeds) > 0 {
+		seedSet := make(exactSet)
+		for _, number := range seeds {
+			seedSet.Add(number)
+		}
+		genOpts = append(genOpts, WithFilter(countRejected(excludeSet(seedSet), &seedDuplicates)))
+	}
+	var collapsed atomic.Int64
+	if opts.noPrefix {
+		// 不同的中间码+尾号组合最多为中间码数×尾号数
+		start, end, step := suffixBounds(opts)
+		combos := min(totalNumbers, EstimateCount([]string{""}, middleCodes, start, end, step))
+		set := newNoPrefixSet(opts.dedupMode, opts.dedupFPRate, uint64(max(combos, 0)))
+		genOpts = append(genOpts, WithFilter(countRejected(firstOccurrence(set), &collapsed)))
+	}
+	if opts.sample > 0 {
+		seed := randomSeed(opts)
+		logf("Sample seed: %d (pass -seed=%d to reproduce this sample)\n", seed, seed)
+		genOpts = append(genOpts, WithSample(opts.sample, seed))
+		if opts.weights != "" {
+			operatorWeights, _ := parseWeights(opts.weights)
+			genOpts = append(genOpts, WithPrefixWeights(prefixWeights(segments, operatorWeights)))
+		}
+	} else if wantsShuffle(opts) {
+		seed := randomSeed(opts)
+		logf("Shuffle seed: %d (pass -seed=%d to reproduce this order)\n", seed, seed)
+		genOpts = append(genOpts, WithShuffle(seed))
+	}
+	if opts.stallRate > 0 {
+		dog = startWatchdog(opts.stallRate, opts.stallWindow, func(err error) {
+			// 写入卡死、生成流程无法返回时直接退出，临时文件保留在磁盘上
+			os.Exit(fatal("generate failed", err))
+		})
+		defer dog.stop()
+		for i := range outputs {
+			outputs[i].W = dog.writer(outputs[i].W)
+		}
+	}
+	if pauser != nil {
+		defer opts.keys.attach(pauser, stop)()
+	}
+	var partCounts []int64
+	if parts != nil {
+		var indexStarts []int64
+		if opts.index {
+			// 没有过滤条件时各部分的号码数可以预先算出，序号按部分顺序接续
+			indexStarts = make([]int64, len(parts))
+			next := opts.indexStart
+			for i, part := range parts {
+				indexStarts[i] = next
+				for _, n := range spaceCountByPrefix(part, middleCodes, spaceOptions(opts, allowed, blocks)) {
+					next += n
 				}
 			}
 		}
+		partCounts, err = generateOutParallel(outputs, parts, middleCodes, genOpts, indexStarts, totalNumbers, reportProgress, middleCounts, prefixCounts)
+		for _, n := range partCounts {
+			generatedCount += n
+		}
+	} else if opts.workers > 1 && parallelWritable(formats, opts) {
+		if opts.maxMemory != "" {
+			maxMemory, _ := parseByteSize(opts.maxMemory)
+			batches := inFlightBatches(opts.workers, int64(opts.batchSize)*int64(layout.totalLen()+1), maxMemory)
+			logf("-max-memory %s: up to %d batches of %d numbers wait for the writer\n", opts.maxMemory, batches, opts.batchSize)
+			if batches < opts.workers {
+				logf("⚠️ Fewer batches than -workers fit into -max-memory, some workers will idle; lower -batch-size or raise -max-memory\n")
+			}
+		}
+		generatedCount, err = GenerateParallel(outputs[0].W, prefixes, middleCodes, opts.workers, opts.batchSize, genOpts...)
+	} else {
+		generatedCount, err = GenerateMulti(outputs, prefixes, middleCodes, genOpts...)
+	}
+	if dog != nil {
+		dog.stop()
+	}
+	if progress != nil {
+		progress.finish()
+	}
+	// 超时后保留已写入的号码，照常提交输出和打印汇总，最后再返回超时错误
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if timedOut {
+		logf("⏱️ Timed out after %s, keeping the %d numbers written so far\n", opts.timeout, generatedCount)
+		err = nil
+	}
+	// 键盘输入q停止时同样保留已写入的号码
+	if errors.Is(err, context.Canceled) && opts.keys != nil {
+		logf("⏹️ Stopped from the keyboard, keeping the %d numbers written so far\n", generatedCount)
+		err = nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	planned, written := totalNumbers+int64(len(seeds)), generatedCount+int64(len(seeds))
+	for _, f := range files {
+		if f.format == "txt" && header != "" && written != planned && !f.rewritable() {
+			logf("⚠️ Warning: the header of %s states the planned %d numbers, compressed, zipped or uploaded output cannot be rewritten\n", f.path, planned)
+			continue
+		}
+		if f.format == "txt" && header != "" && written != planned {
+			// 过滤后实际数量少于预计，用空格补齐到原长度后覆盖文件头
+			actual := headerLine(segments, written)
+			actual += strings.Repeat(" ", len(header)-len(actual))
+			if _, err := f.file.WriteAt([]byte(actual), 0); err != nil {
+				return 0, fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+	}
+	for _, f := range files {
+		if err := f.commit(); err != nil {
+			return 0, err
+		}
+	}
+	if archive != nil {
+		manifest := zipManifest{
+			Generated:   time.Now().Format(time.RFC3339),
+			Command:     os.Args,
+			Numbers:     written,
+			Prefixes:    prefixes,
+			MiddleCodes: middleCodes,
+			Layout:      effectiveLayout{layout.prefixLen, layout.middleLen, layout.suffixLen},
+		}
+		for _, f := range files {
+			manifest.Files = append(manifest.Files, zipManifestFile{Name: f.path, Format: f.format, Bytes: f.w.n})
+		}
+		if err := archive.finish(manifest); err != nil {
+			return 0, err
+		}
+	}
+
+	logf("✅ Generation completed! Actual total numbers generated: %d\n", generatedCount)
+	for i, f := range files {
+		if partCounts != nil {
+			logf("Output (%s): %s, %d numbers from prefixes %s-%s\n", f.format, f.path, partCounts[i], parts[i][0], parts[i][len(parts[i])-1])
+			continue
+		}
+		if archive != nil {
+			logf("Output (%s): %s in %s\n", f.format, f.path, opts.zip)
+			continue
+		}
+		logf("Output (%s): %s\n", f.format, f.path)
+		if f.compressed() {
+			if ratio, err := f.compressionRatio(); err == nil {
+				logf("Compressed to %.1f%% of %d bytes (%s)\n", ratio*100, f.w.n, opts.compress)
+			}
+		}
+	}
+	if opts.sepFuzz {
+		var examples []string
+		for _, style := range separatorFuzzStyles {
+			template, _ := ParseTemplate(style)
+			examples = append(examples, template.format(prefixes[0], middleCodes[0], 0, layout.suffixLen))
+		}
+		fmt.Fprintf(infoOut, "Separator styles (cycled, for parser testing): %s\n", strings.Join(examples, " | "))
+	}
+	if opts.checksum != "" {
+		logf("Numbers passing the %s checksum: %d\n", opts.checksum, checksumPassed.Load())
+	}
+	if opts.blocklist != "" {
+		logf("Blocked %d numbers matching -blocklist-regex\n", blocked.Load())
+	}
+	if opts.dedupAgainst != "" {
+		logf("Skipped %d numbers already present in %s\n", duplicates.Load(), opts.dedupAgainst)
+	}
+	if opts.deltaAgainst != "" {
+		logf("Delta against %s: %d new numbers written, %d already present\n", opts.deltaAgainst, generatedCount, unchanged.Load())
+	}
+	if opts.complement != "" {
+		reportComplement(opts.complement, unassigned.Load(), assigned.Load(),
+			spaceCountByPrefix(prefixes, middleCodes, spaceOptions(opts, allowed, blocks)))
+	}
+	if opts.noPrefix {
+		logf("Collapsed %d duplicate middle+suffix lines from other prefixes (-no-prefix, %s mode)\n", collapsed.Load(), opts.dedupMode)
+	}
+	if opts.seedFile != "" {
+		logf("Seed numbers included from %s: %d (%d generated numbers skipped as duplicates of them)\n",
+			opts.seedFile, len(seeds), seedDuplicates.Load())
+	}
+	reportOperatorSuffixRanges(config.OperatorSuffixRanges, segments)
+	reportMiddleCounts(middleCodes, middleCounts, opts.verbose, truncatedBy(opts, generatedCount, timedOut))
+	if opts.report != "" {
+		if err := writePrefixReport(opts.report, prefixes, operators, prefixCounts); err != nil {
+			return generatedCount, err
+		}
+		logf("Per-prefix report: %s\n", opts.report)
+	}
+	if stats != nil {
+		r := stats.report(prefixes, operators, prefixCounts)
+		printStats(r)
+		if opts.statsJSON != "" {
+			if err := writeStatsJSON(opts.statsJSON, r); err != nil {
+				return generatedCount, err
+			}
+			logf("Statistics (JSON): %s\n", opts.statsJSON)
+		}
+	}
+	if timedOut {
+		return generatedCount, fmt.Errorf("timed out after writing %d numbers (-timeout %s): %w", generatedCount, opts.timeout, context.DeadlineExceeded)
 	}
+	return generatedCount, nil
+}
 
-	fmt.Printf("✅ Generation completed! Actual total numbers generated: %d\n", generatedCount)
+// 号码拼接方式：-separator-fuzz或-template
+func formatOptions(opts options) []Option {
+	if opts.e164 {
+		template, _ := ParseTemplate(e164Template)
+		return []Option{WithTemplate(template)}
+	}
+	if opts.noPrefix {
+		template, _ := ParseTemplate(noPrefixTemplate)
+		return []Option{WithTemplate(template)}
+	}
+	if opts.sepFuzz {
+		var templates []Template
+		for _, style := range separatorFuzzStyles {
+			template, _ := ParseTemplate(style)
+			templates = append(templates, template)
+		}
+		return []Option{WithTemplateCycle(templates)}
+	}
+	if opts.template != "" {
+		template, _ := ParseTemplate(opts.template)
+		if !template.isDefault(layout.suffixLen) {
+			return []Option{WithTemplate(template)}
+		}
+	}
 	return nil
 }
+
+// 按号段统计组合空间中的号码数量
+func spaceCountByPrefix(prefixes, middleCodes []string, spaceOpts []Option) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, b := range newGenConfig(spaceOpts).buildSpace(prefixes, middleCodes).blocks {
+		counts[b.prefix] += int64(b.suffixes.Len())
+	}
+	return counts
+}
+
+// 决定组合空间（哪些号段、中间码和尾号）的生成参数，生成和估算共用
+func spaceOptions(opts options, allowed map[string][]string, blocks []Block) []Option {
+	spaceOpts := []Option{
+		WithSuffixLen(layout.suffixLen),
+		WithSuffixRange(suffixBounds(opts)),
+	}
+	if opts.suffixLens != "" {
+		minLen, maxLen, _ := parseSuffixLenRange(opts.suffixLens)
+		spaceOpts = append(spaceOpts, WithSuffixLenRange(minLen, maxLen))
+	}
+	if opts.suffixDigits != "" {
+		digits, _ := parseSuffixDigits(opts.suffixDigits)
+		spaceOpts = append(spaceOpts, WithSuffixDigits(digits))
+	}
+	if allowed != nil {
+		spaceOpts = append(spaceOpts, WithPrefixMiddleCodes(allowed))
+	}
+	if opts.reverseSuffix {
+		spaceOpts = append(spaceOpts, WithReverseSuffix())
+	}
+	if opts.sorted {
+		spaceOpts = append(spaceOpts, WithSorted())
+	}
+	if opts.shuffleSuffix {
+		spaceOpts = append(spaceOpts, WithSuffixShuffle(opts.suffixSeed))
+	}
+	if opts.perPair > 0 {
+		spaceOpts = append(spaceOpts, WithPerPair(opts.perPair))
+	}
+	if opts.shard != "" {
+		r, m, _ := parseShard(opts.shard)
+		spaceOpts = append(spaceOpts, WithShard(r, m))
+	}
+	if opts.suffixRanges != nil {
+		spaceOpts = append(spaceOpts, WithPrefixSuffixRanges(opts.suffixRanges))
+	}
+	if blocks != nil {
+		spaceOpts = append(spaceOpts, WithBlocks(blocks))
+	}
+	return spaceOpts
+}
+
+// 文件头注释，多数字典工具会忽略以#开头的行
+func headerLine(segments Segments, totalNumbers int64) string {
+	var operators []string
+	for _, operator := range operatorOrder {
+		if len(segments.Prefixes(operator)) > 0 {
+			operators = append(operators, operator)
+		}
+	}
+	return fmt.Sprintf("# Generated %s, operators=%s, %d numbers",
+		time.Now().Format("2006-01-02"), strings.Join(operators, ","), totalNumbers)
+}
+
+// 列出没有产生任何号码的中间码，便于发现过严的过滤条件；-v时列出每个中间码的数量
+// truncation不为空时生成被该参数提前结束，没有号码的中间码只是没有轮到，不再当作被过滤掉
+func reportMiddleCounts(middleCodes []string, counts map[string]int64, verbose bool, truncation string) {
+	var empty []string
+	for _, code := range middleCodes {
+		if counts[code] == 0 {
+			empty = append(empty, code)
+		}
+		if verbose {
+			logf("  middle code %s: %d numbers\n", code, counts[code])
+		}
+	}
+	switch {
+	case len(empty) == 0:
+	case truncation != "":
+		logf("Output was truncated by %s, %d middle code(s) were not reached: %v\n", truncation, len(empty), empty)
+	default:
+		logf("⚠️ %d middle code(s) produced zero numbers: %v\n", len(empty), empty)
+	}
+}
+
+// 提前结束生成的参数，生成完整个号码空间时为空
+func truncatedBy(opts options, generatedCount int64, timedOut bool) string {
+	switch {
+	case timedOut:
+		return "-timeout"
+	case opts.sample > 0:
+		return "-sample"
+	case opts.limit > 0 && generatedCount >= opts.limit:
+		return "-limit"
+	}
+	return ""
+}
+
+// 通过创建并删除一个临时文件检查目录是否可写
+func checkWritableDir(dir string) error {
+	file, err := os.CreateTemp(dir, ".phonedict-write-check-*")
+	if err != nil {
+		return fmt.Errorf("output directory %s is not writable (%v), choose a writable location with -out=/path/to/phonedict.txt", dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// 检查-out：远程地址不需要本地目录，本地路径要求所在目录可写
+func checkOutputDir(out string) error {
+	if err := checkRemoteOutput(out); err != nil {
+		return err
+	}
+	if isRemoteOutput(out) {
+		return nil
+	}
+	return checkWritableDir(filepath.Dir(out))
+}
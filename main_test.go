@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 运行fn并返回它写到标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, info := os.Stdout, infoOut
+	os.Stdout, infoOut = w, w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	defer func() {
+		os.Stdout, infoOut = stdout, info
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestCheckWritableDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritableDir(dir); err != nil {
+		t.Fatalf("writable dir rejected: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("write check left %d file(s) behind", len(entries))
+	}
+}
+
+func TestCheckWritableDirReadOnly(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can write to read-only directories")
+	}
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0o755) })
+	err := checkWritableDir(dir)
+	if err == nil {
+		t.Fatal("read-only dir accepted")
+	}
+	if !strings.Contains(err.Error(), "-out=") {
+		t.Fatalf("error does not suggest -out: %v", err)
+	}
+}
+
+func TestParseMiddleCodesWildcards(t *testing.T) {
+	codes, err := parseMiddleCodes("05*")
+	if err != nil {
+		t.Fatalf("05*: %v", err)
+	}
+	if len(codes) != 100 || codes[0] != "0500" || codes[99] != "0599" {
+		t.Fatalf("05* expanded to %d codes %v..%v", len(codes), codes[0], codes[len(codes)-1])
+	}
+
+	codes, err = parseMiddleCodes("0?37")
+	if err != nil {
+		t.Fatalf("0?37: %v", err)
+	}
+	want := []string{"0037", "0137", "0237", "0337", "0437", "0537", "0637", "0737", "0837", "0937"}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Fatalf("0?37 expanded to %v", codes)
+	}
+
+	codes, err = parseMiddleCodes("0?37,05*")
+	if err != nil {
+		t.Fatalf("0?37,05*: %v", err)
+	}
+	if len(codes) != 109 {
+		t.Fatalf("overlapping patterns gave %d codes, want 109 after dedup", len(codes))
+	}
+
+	for _, pattern := range []string{"0?3", "05*9", "0?3x", "?????"} {
+		if codes, err := parseMiddleCodes(pattern); err == nil {
+			t.Errorf("%s expanded to %v, want an error", pattern, codes)
+		}
+	}
+}
+
+// 内置号段表：每个号段只出现一次，按运营商顺序排列，各种视图都由list得出
+func TestDefaultSegmentsStructure(t *testing.T) {
+	segments := initDefaultSegments()
+	if len(segments.list) != 40 {
+		t.Fatalf("got %d built-in prefixes, want 40", len(segments.list))
+	}
+	seen := make(map[string]bool)
+	order := 0
+	for _, p := range segments.list {
+		if len(p.Code) != 3 || p.Code[0] != '1' || seen[p.Code] {
+			t.Errorf("prefix %q is invalid or listed twice", p.Code)
+		}
+		seen[p.Code] = true
+		for order < len(operatorOrder) && operatorOrder[order] != p.Operator {
+			order++
+		}
+		if order == len(operatorOrder) {
+			t.Fatalf("prefix %s of %q is out of operator order", p.Code, p.Operator)
+		}
+	}
+
+	operators := segments.Operators()
+	var joined []string
+	for _, operator := range operatorOrder {
+		prefixes := segments.Prefixes(operator)
+		for _, code := range prefixes {
+			if operators[code] != operator {
+				t.Errorf("Operators()[%s] = %q, want %q", code, operators[code], operator)
+			}
+		}
+		joined = append(joined, prefixes...)
+	}
+	if strings.Join(joined, ",") != strings.Join(segments.All(), ",") || len(operators) != len(segments.list) {
+		t.Fatal("Prefixes per operator do not add up to All")
+	}
+	if got := len(segments.Prefixes(operatorMobile)); got != 20 {
+		t.Errorf("got %d mobile prefixes, want 20", got)
+	}
+	if segments.Prefixes("nobody") != nil {
+		t.Error("an unknown operator has prefixes")
+	}
+	if operators["137"] != operatorMobile || operators["130"] != operatorUnicom || operators["133"] != operatorTelecom {
+		t.Errorf("wrong operators for 137, 130 and 133: %v", operators)
+	}
+
+	// 过滤和抽样保留号段的运营商
+	only := segments.Only([]string{operatorTelecom, operatorUnicom})
+	if strings.Join(only.All(), ",") != strings.Join(append(segments.Prefixes(operatorUnicom), segments.Prefixes(operatorTelecom)...), ",") {
+		t.Errorf("Only kept %v", only.All())
+	}
+	sampled, err := segments.Sample(10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range sampled.list {
+		if operators[p.Code] != p.Operator {
+			t.Errorf("sampled prefix %s lost its operator: %q", p.Code, p.Operator)
+		}
+	}
+
+	// 每次返回独立的副本
+	segments.list[0].Code = "000"
+	if initDefaultSegments().list[0].Code == "000" {
+		t.Fatal("modifying the returned segments changed the built-in table")
+	}
+}
+
+func TestSegmentsSample(t *testing.T) {
+	segments := initDefaultSegments()
+	for _, k := range []int{1, 7, len(segments.All())} {
+		sampled, err := segments.Sample(k, 42)
+		if err != nil {
+			t.Fatalf("Sample(%d) returned %v", k, err)
+		}
+		used := make(map[string]bool)
+		err = ForEachNumber(sampled.All(), []string{"0537"}, func(number string) error {
+			used[number[:3]] = true
+			return nil
+		}, WithSuffixRange(0, 9, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(used) != k {
+			t.Errorf("Sample(%d) generated numbers with %d distinct prefixes", k, len(used))
+		}
+		again, _ := segments.Sample(k, 42)
+		if strings.Join(again.All(), ",") != strings.Join(sampled.All(), ",") {
+			t.Errorf("Sample(%d) is not reproducible with the same seed", k)
+		}
+	}
+	if _, err := segments.Sample(len(segments.All())+1, 42); err == nil {
+		t.Fatal("sampling more prefixes than available was accepted")
+	}
+}
+
+func TestGeneratePhoneNumbersTimeout(t *testing.T) {
+	t.Chdir(t.TempDir())
+	opts := legacyOptions(t, "-timeout", "50ms", "-force")
+	middleCodes, err := parseMiddleCodes("0000-9999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	captureStdout(t, func() {
+		n, err = generatePhoneNumbers(initDefaultSegments(), Config{MiddleCodes: middleCodes}, opts)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "timed out after writing") {
+		t.Fatalf("got error %v, want a timeout", err)
+	}
+	if exitCodes[errorKind(err)] != 4 {
+		t.Fatalf("timeout maps to exit code %d, want 4", exitCodes[errorKind(err)])
+	}
+	data, err := os.ReadFile("phonedict.txt")
+	if err != nil {
+		t.Fatalf("partial output was not kept: %v", err)
+	}
+	if got := int64(bytes.Count(data, []byte("\n"))); got != n || n == 0 {
+		t.Fatalf("file holds %d lines, %d numbers reported", got, n)
+	}
+}
+
+func TestExpandMiddleToken(t *testing.T) {
+	for _, tc := range []struct {
+		token   string
+		want    []string
+		invalid bool // 普通中间码不合法，调用方可选择跳过
+		err     bool
+	}{
+		{token: "0537", want: []string{"0537"}},
+		{token: " 0537 ", want: []string{"0537"}},
+		{token: "0100-0103", want: []string{"0100", "0101", "0102", "0103"}},
+		{token: "0100 - 0101", want: []string{"0100", "0101"}},
+		{token: "0537-0537", want: []string{"0537"}},
+		{token: "053?", want: []string{"0530", "0531", "0532", "0533", "0534", "0535", "0536", "0537", "0538", "0539"}},
+		{token: "05?7", want: []string{"0507", "0517", "0527", "0537", "0547", "0557", "0567", "0577", "0587", "0597"}},
+		{token: "0537*", want: []string{"0537"}},
+		{token: "", invalid: true},
+		{token: "537", invalid: true},
+		{token: "05a7", invalid: true},
+		{token: "0103-0100", err: true},
+		{token: "0100-01", err: true},
+		{token: "0*3", err: true},
+	} {
+		got, err := expandMiddleToken(tc.token)
+		switch {
+		case tc.invalid:
+			if !errors.Is(err, errInvalidMiddleCode) {
+				t.Errorf("expandMiddleToken(%q) returned %v, %v; want errInvalidMiddleCode", tc.token, got, err)
+			}
+		case tc.err:
+			if err == nil || errors.Is(err, errInvalidMiddleCode) {
+				t.Errorf("expandMiddleToken(%q) returned %v, %v; want a pattern error", tc.token, got, err)
+			}
+		case err != nil:
+			t.Errorf("expandMiddleToken(%q) returned %v", tc.token, err)
+		case strings.Join(got, ",") != strings.Join(tc.want, ","):
+			t.Errorf("expandMiddleToken(%q) = %v, want %v", tc.token, got, tc.want)
+		}
+	}
+}
+
+// 提前结束的生成不把没轮到的中间码报告为被过滤掉
+func TestReportMiddleCounts(t *testing.T) {
+	counts := map[string]int64{"0537": 3}
+	codes := []string{"0537", "0100"}
+	for _, tc := range []struct {
+		truncation, want, not string
+	}{
+		{"", "⚠️ 1 middle code(s) produced zero numbers: [0100]", "truncated"},
+		{"-limit", "Output was truncated by -limit, 1 middle code(s) were not reached: [0100]", "produced zero numbers"},
+	} {
+		out := captureStdout(t, func() {
+			reportMiddleCounts(codes, counts, true, tc.truncation)
+		})
+		if !strings.Contains(out, tc.want) || strings.Contains(out, tc.not) {
+			t.Errorf("truncation %q printed:\n%s", tc.truncation, out)
+		}
+		if !strings.Contains(out, "  middle code 0100: 0 numbers\n") {
+			t.Errorf("verbose counts are missing:\n%s", out)
+		}
+	}
+
+	for _, tc := range []struct {
+		args     []string
+		count    int64
+		timedOut bool
+		want     string
+	}{
+		{nil, 10, false, ""},
+		{[]string{"-limit", "3"}, 3, false, "-limit"},
+		{[]string{"-limit", "30"}, 10, false, ""},
+		{[]string{"-sample", "5"}, 5, false, "-sample"},
+		{[]string{"-timeout", "1s"}, 7, true, "-timeout"},
+	} {
+		if got := truncatedBy(legacyOptions(t, tc.args...), tc.count, tc.timedOut); got != tc.want {
+			t.Errorf("truncatedBy(%v) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// -format=mask只能描述每个组合的完整尾号范围，不能与改变号码集合、顺序或格式的参数同时使用
+var maskIncompatibleFlags = []string{
+	"suffix-start", "suffix-end", "suffix-step", "shard", "per-pair", "block-file", "reverse-suffix",
+	"limit", "sample", "weights", "shuffle", "seed", "daily-seed", "interleave",
+	"checksum", "blocklist-regex", "dedup-against", "delta-against", "complement", "seed-file", "template", "separator-fuzz", "output-base", "header", "index", "suffix-digits",
+}
+
+func checkMaskOptions(formats []string, opts options) error {
+	hasMask := false
+	for _, format := range formats {
+		hasMask = hasMask || format == "mask"
+	}
+	if !hasMask {
+		return nil
+	}
+	if len(formats) > 1 {
+		return fmt.Errorf("-format=mask cannot be combined with other formats")
+	}
+	if opts.compress != "" && opts.compress != "none" {
+		// hashcat不能直接读取压缩的掩码文件
+		return fmt.Errorf("-format=mask cannot be compressed, hashcat reads mask files uncompressed")
+	}
+	for _, name := range maskIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-format=mask cannot be used with -%s (a mask always covers every suffix)", name)
+		}
+	}
+	return nil
+}
+
+// 组合对应的hashcat掩码，如号段137、中间码0537、4位尾号为1370537?d?d?d?d
+func maskLine(prefix, middle string, suffixLen int) string {
+	return prefix + middle + strings.Repeat("?d", suffixLen)
+}
+
+// 每个号段+中间码组合输出一行hashcat掩码，由hashcat在破解时展开尾号，返回掩码行数
+func generateMasks(prefixes, middleCodes []string, allowed map[string][]string, opts options) (int64, error) {
+	s := newGenConfig(spaceOptions(opts, allowed, nil)).buildSpace(prefixes, middleCodes)
+	fmt.Fprintf(infoOut, "\n📱 Mask generation plan:\n")
+	fmt.Fprintf(infoOut, "Masks: %d | Numbers covered: %d (%d per mask)\n", len(s.blocks), s.total, pow10(layout.suffixLen))
+
+	f, err := createOutput(opts.out, "mask", opts.compress, opts.writeRetries)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(f.w)
+	for _, b := range s.blocks {
+		if _, err := w.WriteString(maskLine(b.prefix, b.middle, layout.suffixLen) + "\n"); err != nil {
+			f.abort()
+			return 0, fmt.Errorf("failed to write to file: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.abort()
+		return 0, fmt.Errorf("failed to write to file: %v", err)
+	}
+	if err := f.commit(); err != nil {
+		f.abort()
+		return 0, err
+	}
+	logf("✅ Mask file completed! Masks written: %d\n", len(s.blocks))
+	logf("Output (mask): %s\n", f.path)
+	fmt.Fprintf(infoOut, "Use it as a hashcat mask file: hashcat -a 3 -m <hash-type> hashes.txt %s\n", f.path)
+	return int64(len(s.blocks)), nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaskLine(t *testing.T) {
+	if got := maskLine("137", "0537", 4); got != "1370537?d?d?d?d" {
+		t.Fatalf("maskLine = %q, want 1370537?d?d?d?d", got)
+	}
+	if got := maskLine("137", "0537", 2); got != "1370537?d?d" {
+		t.Fatalf("maskLine = %q, want 1370537?d?d", got)
+	}
+}
+
+func TestGenerateMasks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "phonedict.hcmask")
+	opts := legacyOptions(t, "-format", "mask", "-out", path)
+	n, err := generateMasks([]string{"137", "189"}, []string{"0537", "0100"}, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("wrote %d masks, want 4", n)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "1370537?d?d?d?d\n1370100?d?d?d?d\n1890537?d?d?d?d\n1890100?d?d?d?d\n"
+	if string(data) != want {
+		t.Fatalf("mask file is\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestCheckMaskOptions(t *testing.T) {
+	if _, _, err := parseArgs([]string{"-format", "mask", "-limit", "10"}); err == nil || !strings.Contains(err.Error(), "-limit") {
+		t.Fatalf("-format=mask with -limit gave %v", err)
+	}
+}
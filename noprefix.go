@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// -no-prefix只输出中间码和尾号，不同号段会得到相同的行，生成时只保留第一次出现的组合
+const noPrefixTemplate = "{middle}{suffix}"
+
+// 这些参数依赖完整号码或整体抽样，-out-parallel的各部分无法共享去重集合
+var noPrefixIncompatibleFlags = []string{"template", "separator-fuzz", "output-base", "seed-file", "sample", "out-parallel"}
+
+func checkNoPrefixOptions(formats []string, opts options) error {
+	if !opts.noPrefix {
+		return nil
+	}
+	for _, format := range formats {
+		if format == "binary" || format == "mask" {
+			return fmt.Errorf("-no-prefix cannot be used with -format=%s", format)
+		}
+	}
+	for _, name := range noPrefixIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-no-prefix cannot be used with -%s", name)
+		}
+	}
+	return nil
+}
+
+// 去重集合：exact为精确集合，bloom按最多n个组合和误判率fpRate建立布隆过滤器
+func newNoPrefixSet(mode string, fpRate float64, n uint64) numberSet {
+	if mode == "bloom" {
+		return newBloomFilter(n, fpRate)
+	}
+	return make(exactSet)
+}
+
+// 只放行第一次出现的号码并把它加入set，可被并发调用；必须是最后一个过滤条件，
+// 否则被后面的条件丢弃的号码也会被记为已输出
+func firstOccurrence(set numberSet) Filter {
+	var mu sync.Mutex
+	return func(number string) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if set.Contains(number) {
+			return false
+		}
+		set.Add(number)
+		return true
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// -out-parallel按号段切分输出，不能与依赖全局顺序或单一输出的参数同时使用
+var outParallelIncompatibleFlags = []string{
+	"out", "limit", "sample", "weights", "shuffle", "seed", "daily-seed", "interleave",
+	"seed-file", "header", "no-trailing-newline", "workers", "stall-rate",
+}
+
+// 解析-out-parallel的逗号分隔路径，至少两个且不能重复
+func parseOutParallel(input string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(input, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if seen[path] {
+			return nil, fmt.Errorf("-out-parallel lists %s twice", path)
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	if len(paths) < 2 {
+		return nil, fmt.Errorf("-out-parallel needs at least two comma-separated paths (use -out for a single file)")
+	}
+	return paths, nil
+}
+
+func checkOutParallelOptions(opts options) error {
+	if opts.outParallel == "" {
+		return nil
+	}
+	if _, err := parseOutParallel(opts.outParallel); err != nil {
+		return err
+	}
+	if opts.index && (opts.checksum != "" || opts.blocklist != "" || opts.dedupAgainst != "" || opts.complement != "") {
+		// 过滤后各部分的号码数事先未知，无法让序号跨文件连续
+		return fmt.Errorf("-index with -out-parallel cannot be combined with -checksum, -blocklist-regex, -dedup-against or -complement")
+	}
+	if formats, err := parseFormats(opts.format); err == nil && (len(formats) > 1 || formats[0] == "mask" || formats[0] == "ranges") {
+		return fmt.Errorf("-out-parallel writes a single txt, csv, jsonl or binary format, got -format %s", opts.format)
+	}
+	for _, name := range outParallelIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-out-parallel cannot be used with -%s", name)
+		}
+	}
+	return nil
+}
+
+// 各输出路径，未指定-out-parallel时只有-out，指定-zip时只有zip文件
+func outputPaths(opts options) []string {
+	if opts.zip != "" {
+		return []string{opts.zip}
+	}
+	if opts.outParallel == "" {
+		return []string{opts.out}
+	}
+	paths, _ := parseOutParallel(opts.outParallel)
+	return paths
+}
+
+// 一个输出文件的路径（不含压缩扩展名）和格式
+type outputTarget struct {
+	path, format string
+}
+
+// 各输出文件：每种格式一个，-out-parallel时每个路径一个部分（只有一种格式）
+func outputTargets(opts options, formats []string) []outputTarget {
+	var targets []outputTarget
+	if opts.outParallel != "" {
+		for _, path := range outputPaths(opts) {
+			targets = append(targets, outputTarget{path, formats[0]})
+		}
+		return targets
+	}
+	for _, format := range formats {
+		targets = append(targets, outputTarget{formatPath(opts.out, format, len(formats) > 1), format})
+	}
+	return targets
+}
+
+// 按生成顺序把号段切成n段连续的部分，各部分的号码数尽量接近；
+// 按顺序拼接各部分的输出即得到单个文件的输出
+func splitPrefixes(prefixes, middleCodes []string, n int, spaceOpts []Option) ([][]string, error) {
+	if newGenConfig(spaceOpts).sorted {
+		prefixes = sortedCopy(prefixes)
+	}
+	if len(prefixes) < n {
+		return nil, fmt.Errorf("-out-parallel has %d paths but only %d prefixes are selected", n, len(prefixes))
+	}
+	counts := spaceCountByPrefix(prefixes, middleCodes, spaceOpts)
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+	parts := make([][]string, n)
+	var cumulative int64
+	part := 0
+	for i, prefix := range prefixes {
+		// 剩余的号段刚好够每个部分一个时不再合并
+		if part < n-1 && len(parts[part]) > 0 &&
+			(cumulative >= total*int64(part+1)/int64(n) || len(prefixes)-i == n-1-part) {
+			part++
+		}
+		parts[part] = append(parts[part], prefix)
+		cumulative += counts[prefix]
+	}
+	return parts, nil
+}
+
+// 每个部分由各自的协程写入各自的输出，返回各部分写入的号码数。各部分单独统计中间码和号段的数量，
+// 结束后再合并到middleCounts和prefixCounts；只有每10000个号码一次的进度汇总需要加锁
+// indexStarts不为nil时为各部分第一个号码的序号
+func generateOutParallel(outputs []Output, parts [][]string, middleCodes []string, opts []Option, indexStarts []int64, total int64,
+	progress ProgressFunc, middleCounts, prefixCounts map[string]int64) ([]int64, error) {
+	counts := make([]int64, len(parts))
+	errs := make([]error, len(parts))
+	partMiddles := make([]map[string]int64, len(parts))
+	partPrefixes := make([]map[string]int64, len(parts))
+	partDone := make([]int64, len(parts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := range parts {
+		partMiddles[i], partPrefixes[i] = make(map[string]int64), make(map[string]int64)
+		partOpts := append(append([]Option{}, opts...),
+			withObserver(func(prefix, middle string) {
+				partMiddles[i][middle]++
+				partPrefixes[i][prefix]++
+			}),
+			WithProgress(func(done, _ int64) {
+				mu.Lock()
+				defer mu.Unlock()
+				partDone[i] = done
+				var sum int64
+				for _, n := range partDone {
+					sum += n
+				}
+				progress(sum, total)
+			}))
+		if indexStarts != nil {
+			partOpts = append(partOpts, WithIndexStart(indexStarts[i]))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counts[i], errs[i] = GenerateMulti(outputs[i:i+1], parts[i], middleCodes, partOpts...)
+		}()
+	}
+	wg.Wait()
+	for i := range parts {
+		for middle, n := range partMiddles[i] {
+			middleCounts[middle] += n
+		}
+		for prefix, n := range partPrefixes[i] {
+			prefixCounts[prefix] += n
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return counts, err
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 序号跨-out-parallel的文件边界连续
+func TestOutParallelIndexAcrossParts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(envMiddleCodes, "")
+	if err := os.WriteFile("prefixes.txt", []byte("137\n138\n139\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	segments, err := loadPrefixFile("prefixes.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := legacyOptions(t, "-out-parallel", "a.txt,b.txt", "-index", "-index-start", "5", "-force")
+	captureStdout(t, func() {
+		_, err = generatePhoneNumbers(segments, Config{MiddleCodes: []string{"0537"}}, opts)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, path := range []string{"a.txt", "b.txt"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(part) == 0 || part[0] == "" {
+			t.Fatalf("%s is empty", path)
+		}
+		lines = append(lines, part...)
+	}
+	if len(lines) != 30000 {
+		t.Fatalf("got %d lines, want 30000", len(lines))
+	}
+	for i, line := range lines {
+		index, number, ok := strings.Cut(line, ",")
+		if !ok {
+			t.Fatalf("line %d %q has no index", i, line)
+		}
+		if got, err := strconv.Atoi(index); err != nil || got != i+5 {
+			t.Fatalf("line %d has index %q, want %d", i, index, i+5)
+		}
+		if want := fmt.Sprintf("%s0537%04d", []string{"137", "138", "139"}[i/10000], i%10000); number != want {
+			t.Fatalf("line %d is %q, want %q", i, number, want)
+		}
+	}
+}
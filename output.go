@@ -0,0 +1,309 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// 支持的输出格式
+var outputFormats = []string{"txt", "csv", "jsonl", "mask", "binary", "ranges"}
+
+// 解析逗号分隔的输出格式列表，去重并保持输入顺序
+func parseFormats(input string) ([]string, error) {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, format := range strings.Split(input, ",") {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if format == "" || seen[format] {
+			continue
+		}
+		known := false
+		for _, f := range outputFormats {
+			known = known || f == format
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown format %q (must be one of %s)", format, strings.Join(outputFormats, ", "))
+		}
+		formats = append(formats, format)
+		seen[format] = true
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no output format given")
+	}
+	return formats, nil
+}
+
+// 单一格式时直接使用-out，多种格式时把-out的扩展名替换为各格式名（binary为.bin）
+func formatPath(out, format string, multiple bool) string {
+	if !multiple {
+		return out
+	}
+	if format == "binary" {
+		format = "bin"
+	}
+	return strings.TrimSuffix(out, filepath.Ext(out)) + "." + format
+}
+
+// 各格式的列标题行，txt和jsonl没有
+func formatColumns(format string) string {
+	if format == "csv" {
+		return "number,operator,prefix,middle"
+	}
+	return ""
+}
+
+// 每个号码在该格式下大约占用的字节数（含换行符），用于估算输出大小
+func formatRecordSize(format string) int {
+	switch format {
+	case "csv":
+		return layout.totalLen() + layout.prefixLen + layout.middleLen + 20
+	case "jsonl":
+		return layout.totalLen() + 40
+	case "binary":
+		return binaryRecordSize
+	}
+	return layout.totalLen() + 1
+}
+
+// 构造格式编码器，运营商名称通过operatorLabel获取
+func formatEncoder(format string, operators map[string]string) Encoder {
+	switch format {
+	case "csv":
+		return func(r Record) string {
+			return r.Number + "," + csvField(operatorLabel(operators[r.Prefix])) + "," + r.Prefix + "," + r.Middle
+		}
+	case "jsonl":
+		quoted := make(map[string]string)
+		for prefix, operator := range operators {
+			label, _ := json.Marshal(operatorLabel(operator))
+			quoted[prefix] = string(label)
+		}
+		return func(r Record) string {
+			operator, ok := quoted[r.Prefix]
+			if !ok {
+				operator = `""`
+			}
+			return `{"number":"` + r.Number + `","operator":` + operator + `}`
+		}
+	}
+	return nil
+}
+
+// -index：txt在号码前加上序号和分隔符，csv增加首列index，jsonl增加index字段
+func indexedEncoder(format string, encode Encoder, delim string) Encoder {
+	switch format {
+	case "csv":
+		return func(r Record) string {
+			return strconv.FormatInt(r.Index, 10) + "," + encode(r)
+		}
+	case "jsonl":
+		return func(r Record) string {
+			return `{"index":` + strconv.FormatInt(r.Index, 10) + "," + encode(r)[1:]
+		}
+	}
+	return func(r Record) string {
+		return strconv.FormatInt(r.Index, 10) + delim + r.Number
+	}
+}
+
+func csvField(s string) string {
+	if strings.ContainsAny(s, ",\"\n") {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return s
+}
+
+// 支持的-compress取值及对应的文件扩展名
+var compressExts = map[string]string{
+	"none": "",
+	"gzip": ".gz",
+	"zstd": ".zst",
+}
+
+func checkCompress(compress string) error {
+	if _, ok := compressExts[compress]; !ok {
+		return fmt.Errorf("invalid -compress %q (must be gzip, zstd or none)", compress)
+	}
+	return nil
+}
+
+// 按-compress创建写入dst的压缩器，none时返回nil
+func newCompressor(dst io.Writer, compress string) (io.WriteCloser, error) {
+	switch compress {
+	case "gzip":
+		return gzip.NewWriter(dst), nil
+	case "zstd":
+		zw, err := zstd.NewWriter(dst)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start zstd compression: %v", err)
+		}
+		return zw, nil
+	}
+	return nil, nil
+}
+
+// 统计写入的字节数，用于计算压缩率
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// 输出文件：先写入临时文件，commit时关闭并重命名为正式文件，保证输出要么完整要么不存在
+type outputFile struct {
+	format   string
+	path     string
+	tmpPath  string
+	file     *os.File
+	upload   *upload         // 远程输出，此时file为nil
+	archive  *zipArchive     // -zip的条目，直接写入zip时file为nil，否则file为临时文件
+	zw       io.WriteCloser  // -compress的压缩器，不压缩时为nil
+	appender *lockedAppender // -append时直接追加到path，没有临时文件
+	appended bool            // 追加前文件中已有内容
+	w        *countingWriter // 未压缩的数据写入这里
+}
+
+// retries为本地文件写入暂时性错误时的重试次数，远程输出不重试
+func createOutput(path, format, compress string, retries int) (*outputFile, error) {
+	path += compressExts[compress]
+	o := &outputFile{format: format, path: path}
+	var dst io.Writer
+	if isRemoteOutput(path) {
+		u, err := startUpload(path)
+		if err != nil {
+			return nil, err
+		}
+		o.upload, dst = u, u
+	} else {
+		o.tmpPath = path + ".tmp"
+		file, err := os.Create(o.tmpPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file: %v", err)
+		}
+		o.file, dst = file, file
+		if retries > 0 {
+			dst = &retryWriter{w: file, name: path, retries: retries}
+		}
+	}
+	o.w = &countingWriter{w: dst}
+	zw, err := newCompressor(dst, compress)
+	if err != nil {
+		o.abort()
+		return nil, err
+	}
+	if zw != nil {
+		o.zw, o.w.w = zw, zw
+	}
+	return o, nil
+}
+
+func (o *outputFile) compressed() bool {
+	return o.zw != nil
+}
+
+// 压缩输出、远程输出和直接写入zip的输出无法回写文件头
+func (o *outputFile) rewritable() bool {
+	return o.zw == nil && o.file != nil && o.appender == nil
+}
+
+func (o *outputFile) commit() error {
+	if o.archive != nil {
+		return o.archive.add(o)
+	}
+	if o.zw != nil {
+		if err := o.zw.Close(); err != nil {
+			if o.upload != nil {
+				o.upload.cancel()
+			}
+			return fmt.Errorf("failed to finish compressing %s: %v", o.path, err)
+		}
+	}
+	if o.upload != nil {
+		return o.upload.finish()
+	}
+	if o.appender != nil {
+		err := o.appender.flush()
+		if closeErr := o.file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close %s: %v", o.path, closeErr)
+		}
+		return err
+	}
+	if err := o.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %v", o.tmpPath, err)
+	}
+	if err := os.Rename(o.tmpPath, o.path); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %v", o.tmpPath, o.path, err)
+	}
+	return nil
+}
+
+// 压缩率：文件大小占未压缩数据的比例，需在commit之后调用
+func (o *outputFile) compressionRatio() (float64, error) {
+	info, err := os.Stat(o.path)
+	if err != nil {
+		return 0, err
+	}
+	if o.w.n == 0 {
+		return 0, nil
+	}
+	return float64(info.Size()) / float64(o.w.n), nil
+}
+
+// 估算压缩输出大小时压缩的样本号码数
+const compressionSampleSize = 8192
+
+// 按实际的生成顺序和格式生成并压缩少量样本号码，返回每个号码的平均字节数和压缩后占原始数据的比例；
+// 样本为空时返回0和1
+func sampleCompression(prefixes, middleCodes []string, format, compress string, opts []Option) (float64, float64) {
+	compressed := &countingWriter{w: io.Discard}
+	zw, err := newCompressor(compressed, compress)
+	if err != nil || zw == nil {
+		return 0, 1
+	}
+	raw := &countingWriter{w: zw}
+	sampleOpts := append(append([]Option{}, opts...), WithLimit(compressionSampleSize))
+	count, err := GenerateMulti([]Output{{W: raw, Encode: formatEncoder(format, nil), Binary: format == "binary"}}, prefixes, middleCodes, sampleOpts...)
+	if closeErr := zw.Close(); err != nil || closeErr != nil || count == 0 {
+		return 0, 1
+	}
+	return float64(raw.n) / float64(count), float64(compressed.n) / float64(raw.n)
+}
+
+// 出错时删除不完整的临时文件，追加输出保留已写入的行
+func (o *outputFile) abort() {
+	if o.zw != nil {
+		// 释放压缩器（zstd有后台协程），写出的数据随后丢弃
+		defer o.zw.Close()
+	}
+	if o.archive != nil {
+		if o.file != nil {
+			o.file.Close()
+			os.Remove(o.file.Name())
+		}
+		return
+	}
+	if o.upload != nil {
+		o.upload.cancel()
+		return
+	}
+	if o.appender != nil {
+		// 已追加的完整行无法撤回，不完整的最后一行丢弃
+		o.file.Close()
+		return
+	}
+	o.file.Close()
+	os.Remove(o.tmpPath)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCreateOutputCompressed(t *testing.T) {
+	for _, tc := range []struct {
+		compress string
+		ext      string
+		reader   func(r io.Reader) (io.Reader, error)
+	}{
+		{"gzip", ".gz", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{"zstd", ".zst", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+	} {
+		t.Run(tc.compress, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "phonedict.txt")
+			f, err := createOutput(path, "txt", tc.compress, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Generate(f.w, []string{"137"}, []string{"0537"}); err != nil {
+				f.abort()
+				t.Fatal(err)
+			}
+			if err := f.commit(); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(path + tc.ext)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			r, err := tc.reader(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Fields(string(data))
+			if len(lines) != 10000 || lines[0] != "13705370000" || lines[9999] != "13705379999" {
+				t.Fatalf("decompressed %d lines", len(lines))
+			}
+		})
+	}
+}
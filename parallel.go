@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// 一批拼接好的号码，seq为批次序号，写入协程按序号顺序写出
+type numberBatch struct {
+	seq   int
+	data  []byte // 每个号码后跟一个\n
+	count int64
+	runs  []middleRun
+}
+
+// 连续相同号段+中间码的号码数量，写入协程据此回调observe
+type middleRun struct {
+	prefix, middle string
+	count          int64
+}
+
+// GenerateParallel 输出与Generate相同，但由workers个协程并行拼接号码：每batchSize个下标为一批，
+// 拼好的字节整批经通道交给写入协程，按批次顺序写出，避免逐个号码通过通道的同步开销。
+// 过滤条件会被多个协程同时调用，必须并发安全。抽样、交错输出、多模板轮换和多种尾号位数时退回到单协程的Generate
+func GenerateParallel(w io.Writer, prefixes, middleCodes []string, workers, batchSize int, opts ...Option) (int64, error) {
+	c := newGenConfig(opts)
+	if workers <= 1 || batchSize < 1 || c.sample > 0 || c.interleave != nil || len(c.templates) > 1 || c.observeNumber != nil || len(c.suffixLens) > 0 {
+		return Generate(w, prefixes, middleCodes, opts...)
+	}
+	if c.suffixLen < 1 {
+		return 0, fmt.Errorf("suffix length must be positive, got %d", c.suffixLen)
+	}
+	s := c.buildSpace(prefixes, middleCodes)
+	total := c.total(prefixes, middleCodes)
+	index := func(i uint64) uint64 { return i }
+	if c.shuffle {
+		index = newPermutation(s.total, c.seed).at
+	}
+	format := c.formatter()
+	batches := int((s.total + uint64(batchSize) - 1) / uint64(batchSize))
+
+	jobs := make(chan int)
+	results := make(chan numberBatch)
+	stop := make(chan struct{})
+	// 限制在途批次数，避免慢批次或慢速磁盘导致后面的批次无限堆积在内存中
+	batchBytes := int64(batchSize) * 16
+	if s.total > 0 {
+		batchBytes = int64(batchSize) * int64(len(format(s.at(index(0))))+1)
+	}
+	tokens := make(chan struct{}, inFlightBatches(workers, batchBytes, c.maxMemory))
+	// 写出后的批次缓冲区交给后面的批次复用
+	buffers := sync.Pool{New: func() any { return make([]byte, 0, batchBytes) }}
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := range jobs {
+				start := uint64(seq) * uint64(batchSize)
+				end := min(start+uint64(batchSize), s.total)
+				b := numberBatch{seq: seq, data: buffers.Get().([]byte)[:0]}
+				for i := start; i < end; i++ {
+					prefix, middle, suffix := s.at(index(i))
+					number := format(prefix, middle, suffix)
+					if !c.keep(number) {
+						continue
+					}
+					b.data = append(append(b.data, c.rebase(number)...), '\n')
+					b.count++
+					if last := len(b.runs) - 1; last >= 0 && b.runs[last].prefix == prefix && b.runs[last].middle == middle {
+						b.runs[last].count++
+					} else {
+						b.runs = append(b.runs, middleRun{prefix, middle, 1})
+					}
+				}
+				select {
+				case results <- b:
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+	var cancel <-chan struct{}
+	if c.ctx != nil {
+		cancel = c.ctx.Done()
+	}
+	go func() {
+		defer close(jobs)
+		for seq := 0; seq < batches; seq++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-stop:
+				return
+			case <-cancel:
+				// 已分发的批次照常写出
+				return
+			}
+			select {
+			case jobs <- seq:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	writer := bufio.NewWriter(w)
+	var done int64
+	// 最后一个换行符先不写，下一批写出前或结束时再决定
+	pendingNL := false
+	write := func(b numberBatch) (bool, error) {
+		data, count := b.data, b.count
+		full := true
+		if c.limit > 0 && done+count > c.limit {
+			// 只保留到上限为止的号码
+			count = c.limit - done
+			cut := 0
+			for n := int64(0); n < count; n++ {
+				cut += bytes.IndexByte(data[cut:], '\n') + 1
+			}
+			data = data[:cut]
+			full = false
+		}
+		if count == 0 {
+			return full, nil
+		}
+		if pendingNL {
+			if err := writer.WriteByte('\n'); err != nil {
+				return false, fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+		if _, err := writer.Write(data[:len(data)-1]); err != nil {
+			return false, fmt.Errorf("failed to write to file: %v", err)
+		}
+		pendingNL = true
+		if c.observe != nil {
+			remaining := count
+			for _, run := range b.runs {
+				for n := int64(0); n < run.count && remaining > 0; n++ {
+					c.observe(run.prefix, run.middle)
+					remaining--
+				}
+			}
+		}
+		before := done
+		done += count
+		if done/progressInterval != before/progressInterval {
+			if err := writer.Flush(); err != nil {
+				return false, fmt.Errorf("failed to write to file: %v", err)
+			}
+			if c.progress != nil {
+				c.progress(done, total)
+			}
+		}
+		if c.pause != nil && c.pause.Paused() {
+			if err := writer.Flush(); err != nil {
+				return false, fmt.Errorf("failed to write to file: %v", err)
+			}
+			return full, c.pause.wait(c.ctx)
+		}
+		return full, nil
+	}
+
+	pending := make(map[int]numberBatch)
+	next := 0
+	var err error
+	for b := range results {
+		pending[b.seq] = b
+		for err == nil {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-tokens
+			var more bool
+			if more, err = write(b); err == nil && !more {
+				err = errLimitReached
+			}
+			buffers.Put(b.data)
+		}
+		if err != nil {
+			close(stop)
+			for range results {
+			}
+			break
+		}
+	}
+	var cancelErr error
+	if c.cancelled(err) {
+		// 暂停期间ctx结束
+		cancelErr, err = err, nil
+	}
+	if err != nil && err != errLimitReached {
+		return done, err
+	}
+	if err == nil && next < batches && c.ctx != nil {
+		cancelErr = c.ctx.Err()
+	}
+	if pendingNL && !c.noTrailingNL {
+		if err := writer.WriteByte('\n'); err != nil {
+			return done, fmt.Errorf("failed to write to file: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return done, fmt.Errorf("failed to write to file: %v", err)
+	}
+	if c.progress != nil && done%progressInterval != 0 {
+		c.progress(done, total)
+	}
+	return done, cancelErr
+}
+
+// 在途批次数：默认每个协程2批；设置了maxMemory时按批次大小折算，至少1批。
+// 少于workers时部分协程会一直等待，并行效率下降
+func inFlightBatches(workers int, batchBytes, maxMemory int64) int {
+	if maxMemory <= 0 {
+		return workers * 2
+	}
+	return int(max(1, maxMemory/max(1, batchBytes)))
+}
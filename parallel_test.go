@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 写入很慢的writer，记录写入时已拼接但尚未写出的号码数的最大值
+type slowWriter struct {
+	buf       bytes.Buffer
+	formatted *atomic.Int64
+	maxAhead  int64
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	n, err := w.buf.Write(p)
+	written := int64(bytes.Count(w.buf.Bytes(), []byte("\n")))
+	w.maxAhead = max(w.maxAhead, w.formatted.Load()-written)
+	return n, err
+}
+
+func TestGenerateParallelMaxMemory(t *testing.T) {
+	const workers, batchSize = 8, 1000
+	prefixes := []string{"137"}
+	middleCodes := []string{"0530", "0531", "0532", "0533", "0534", "0535", "0536", "0537", "0538", "0539"}
+	var want bytes.Buffer
+	if _, err := Generate(&want, prefixes, middleCodes); err != nil {
+		t.Fatal(err)
+	}
+
+	var formatted atomic.Int64
+	w := &slowWriter{formatted: &formatted}
+	// 每个号码12字节，上限为两批
+	n, err := GenerateParallel(w, prefixes, middleCodes, workers, batchSize,
+		WithMaxMemory(2*batchSize*12),
+		WithFilter(func(string) bool {
+			formatted.Add(1)
+			return true
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 100000 || !bytes.Equal(w.buf.Bytes(), want.Bytes()) {
+		t.Fatalf("GenerateParallel wrote %d numbers that differ from Generate", n)
+	}
+	// 两批在途、一批正在写出，外加bufio缓冲区中的号码
+	if limit := int64(3*batchSize + 4096/12); w.maxAhead > limit {
+		t.Fatalf("%d numbers were formatted ahead of the writer, want at most %d", w.maxAhead, limit)
+	}
+	if got := inFlightBatches(workers, batchSize*12, 2*batchSize*12); got != 2 {
+		t.Fatalf("inFlightBatches = %d, want 2", got)
+	}
+	if got := inFlightBatches(workers, batchSize*12, 0); got != 2*workers {
+		t.Fatalf("inFlightBatches without a cap = %d, want %d", got, 2*workers)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Pauser 由其他协程暂停和继续生成，零值可直接使用
+type Pauser struct {
+	paused atomic.Bool
+	mu     sync.Mutex
+	resume chan struct{} // 继续时关闭
+}
+
+// Pause 暂停生成，已暂停时不做任何事
+func (p *Pauser) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.paused.Load() {
+		p.resume = make(chan struct{})
+		p.paused.Store(true)
+	}
+}
+
+// Resume 继续生成，未暂停时不做任何事
+func (p *Pauser) Resume() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused.Load() {
+		p.paused.Store(false)
+		close(p.resume)
+	}
+}
+
+// Paused 是否处于暂停状态，每个号码检查一次，只读一个原子变量
+func (p *Pauser) Paused() bool {
+	return p.paused.Load()
+}
+
+// 暂停期间阻塞，直到继续或ctx结束（返回ctx.Err()）
+func (p *Pauser) wait(ctx context.Context) error {
+	p.mu.Lock()
+	paused, resume := p.paused.Load(), p.resume
+	p.mu.Unlock()
+	if !paused {
+		return nil
+	}
+	var cancelled <-chan struct{}
+	if ctx != nil {
+		cancelled = ctx.Done()
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-cancelled:
+		return ctx.Err()
+	}
+}
+
+// WithPause 在两个号码之间检查p：暂停时先刷新缓冲区，保证已写入的都是完整的行，
+// 再等待继续；等待期间WithContext的ctx结束时照常停止
+func WithPause(p *Pauser) Option {
+	return func(c *genConfig) {
+		c.pause = p
+	}
+}
+
+// 交互模式的键盘控制：生成期间输入的p、r、q（回车确认）分别暂停、继续和停止生成，
+// 其余时间的输入原样交给菜单
+type keyControl struct {
+	out    io.Writer
+	mu     sync.Mutex
+	pauser *Pauser // 生成期间非nil
+	stop   context.CancelFunc
+}
+
+// 在后台逐行读取in，返回菜单使用的输入
+func newKeyControl(in io.Reader, out io.Writer) (io.Reader, *keyControl) {
+	pr, pw := io.Pipe()
+	k := &keyControl{out: out}
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if k.handle(scanner.Text()) {
+				continue
+			}
+			if _, err := io.WriteString(pw, scanner.Text()+"\n"); err != nil {
+				return
+			}
+		}
+		pw.CloseWithError(scanner.Err())
+	}()
+	return pr, k
+}
+
+// 生成开始时接管键盘，返回的函数在生成结束后调用
+func (k *keyControl) attach(pauser *Pauser, stop context.CancelFunc) func() {
+	k.mu.Lock()
+	k.pauser, k.stop = pauser, stop
+	k.mu.Unlock()
+	fmt.Fprintln(k.out, "⌨️ Enter p to pause, r to resume or q to stop (keeps the numbers written so far)")
+	return func() {
+		k.mu.Lock()
+		k.pauser, k.stop = nil, nil
+		k.mu.Unlock()
+	}
+}
+
+// 生成期间处理一行输入并返回true，否则返回false
+func (k *keyControl) handle(line string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.pauser == nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "p":
+		k.pauser.Pause()
+		fmt.Fprintln(k.out, "\n⏸️ Paused, the output is flushed; enter r to resume or q to stop")
+	case "r":
+		k.pauser.Resume()
+		fmt.Fprintln(k.out, "▶️ Resumed")
+	case "q":
+		k.stop()
+		fmt.Fprintln(k.out, "\n⏹️ Stopping...")
+	default:
+		fmt.Fprintln(k.out, "Enter p to pause, r to resume or q to stop")
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// 读取-prefix-file：每行一个号段，后面可跟运营商（如170,mobile或170 mobile），跳过空行和#注释行。
+// merge为false时文件中的号段替换内置号段，为true时与内置号段合并去重。
+// 不写运营商的号段按内置号段归类，无法归类时报错（每个号段都需要运营商才能输出标签和按运营商筛选）
+func loadPrefixFile(path string, merge bool) (Segments, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Segments{}, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	builtin := initDefaultSegments()
+	known := builtin.Operators()
+	var segments Segments
+	if merge {
+		segments = builtin
+	}
+	owner := segments.Operators()
+
+	prefixRegex := regexp.MustCompile(fmt.Sprintf(`^\d{%d}$`, layout.prefixLen))
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		prefix := fields[0]
+		if !prefixRegex.MatchString(prefix) || len(fields) > 2 {
+			return Segments{}, fmt.Errorf("line %d of %s: %q is not a %d-digit prefix optionally followed by an operator", lineNo, path, line, layout.prefixLen)
+		}
+		operator := known[prefix]
+		if len(fields) == 2 {
+			operator = strings.ToLower(fields[1])
+			if _, ok := operatorLabels["en"][operator]; !ok {
+				return Segments{}, fmt.Errorf("line %d of %s: unknown operator %q (must be mobile, unicom or telecom)", lineNo, path, fields[1])
+			}
+		}
+		if operator == "" {
+			return Segments{}, fmt.Errorf("line %d of %s: prefix %s is not built in, add its operator (e.g. %s,mobile)", lineNo, path, prefix, prefix)
+		}
+		if current, ok := owner[prefix]; ok {
+			if current != operator {
+				logf("Warning: line %d of %s assigns prefix %s to %s instead of %s\n", lineNo, path, prefix, operator, current)
+				segments.remove(current, prefix)
+			} else {
+				continue
+			}
+		}
+		segments.add(operator, prefix)
+		owner[prefix] = operator
+	}
+	if err := scanner.Err(); err != nil {
+		return Segments{}, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if len(segments.All()) == 0 {
+		return Segments{}, fmt.Errorf("%s contains no prefixes", path)
+	}
+	return segments, nil
+}
+
+// 把号段加到所属运营商的号段末尾，保持按运营商排列的顺序
+func (s *Segments) add(operator, prefix string) {
+	rank := slices.Index(operatorOrder, operator)
+	i := len(s.list)
+	for i > 0 && slices.Index(operatorOrder, s.list[i-1].Operator) > rank {
+		i--
+	}
+	s.list = slices.Insert(s.list, i, Prefix{Code: prefix, Operator: operator})
+}
+
+func (s *Segments) remove(operator, prefix string) {
+	s.list = slices.DeleteFunc(s.list, func(p Prefix) bool {
+		return p.Code == prefix && p.Operator == operator
+	})
+}
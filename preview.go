@@ -0,0 +1,72 @@
+package main
+
+import "fmt"
+
+// 预览时输出的首尾号码个数
+const previewCount = 5
+
+// 打印将要生成的前5个和后5个号码及其运营商（-limit时为前limit个中的最后5个），按下标直接定位，不遍历整个组合空间
+func printPreview(segments Segments, config Config, opts options) error {
+	prefixes := segments.All()
+	allowed := config.prefixMiddles(segments)
+	opts.suffixRanges = config.prefixSuffixRanges(segments)
+	var blocks []Block
+	if opts.blockFile != "" {
+		var err error
+		if blocks, err = loadBlockFile(opts.blockFile, segments, config.MiddleCodes); err != nil {
+			return err
+		}
+		if blocks == nil {
+			blocks = []Block{}
+		}
+	}
+	if opts.shuffleSuffix {
+		opts.suffixSeed = randomSeed(opts)
+	}
+	previewOpts := append(spaceOptions(opts, allowed, blocks), formatOptions(opts)...)
+	if opts.set["output-base"] && opts.outputBase != 10 {
+		previewOpts = append(previewOpts, WithOutputBase(opts.outputBase))
+	}
+	c := newGenConfig(previewOpts)
+	s := c.buildSpace(prefixes, config.MiddleCodes)
+	// 打乱时按同一置换定位，与实际输出顺序一致
+	index := func(i uint64) uint64 { return i }
+	if opts.sample == 0 && wantsShuffle(opts) {
+		if !opts.set["seed"] && !opts.dailySeed {
+			fmt.Fprintln(infoOut, "Note: -shuffle without -seed picks a new order on every run, the preview shows one possible order")
+		}
+		perm := newPermutation(s.total, randomSeed(opts))
+		index = perm.at
+	}
+	if opts.sample > 0 || opts.interleave || opts.checksum != "" || opts.dedupAgainst != "" || opts.deltaAgainst != "" || opts.complement != "" {
+		fmt.Fprintln(infoOut, "Note: the preview follows the combination order and ignores -sample, -interleave and filters")
+	}
+
+	// -limit只输出前limit个，预览的末尾也取到这里为止
+	total := s.total
+	if opts.limit > 0 && uint64(opts.limit) < total {
+		total = uint64(opts.limit)
+	}
+
+	operators := segments.Operators()
+	format := c.formatter()
+	show := func(i uint64) {
+		prefix, middle, suffix := s.at(index(i))
+		fmt.Fprintf(infoOut, "%12d  %-24s %s\n", i+1, c.rebase(format(prefix, middle, suffix)), operatorLabel(operators[prefix]))
+	}
+	fmt.Fprintf(infoOut, "\n🔍 Preview of %d numbers:\n", total)
+	if total <= 2*previewCount {
+		for i := uint64(0); i < total; i++ {
+			show(i)
+		}
+		return nil
+	}
+	for i := uint64(0); i < previewCount; i++ {
+		show(i)
+	}
+	fmt.Fprintf(infoOut, "%12s\n", "...")
+	for i := total - previewCount; i < total; i++ {
+		show(i)
+	}
+	return nil
+}
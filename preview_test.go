@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatBase(t *testing.T) {
+	for _, tc := range []struct {
+		number string
+		base   int
+		want   string
+	}{
+		{"13705370000", 16, "0330e75990"},
+		{"13705370000", 10, "13705370000"},
+		{"00000000255", 16, "00000000ff"},
+		{"13705370000", 36, "06antn68"},
+	} {
+		c := genConfig{outputBase: tc.base}
+		if got := c.rebase(tc.number); got != tc.want {
+			t.Errorf("%s in base %d = %q, want %q", tc.number, tc.base, got, tc.want)
+		}
+	}
+}
+
+func TestPrintPreviewOutputBase(t *testing.T) {
+	opts := legacyOptions(t, "-preview", "-output-base", "16")
+	segments := newSegments(map[string][]string{operatorMobile: {"137"}})
+	out := captureStdout(t, func() {
+		if err := printPreview(segments, Config{MiddleCodes: []string{"0537"}}, opts); err != nil {
+			t.Error(err)
+		}
+	})
+	if !strings.Contains(out, "0330e75990") {
+		t.Fatalf("preview does not show the base-16 numbers:\n%s", out)
+	}
+	if strings.Contains(out, "13705370000") {
+		t.Fatalf("preview shows decimal numbers with -output-base 16:\n%s", out)
+	}
+}
+
+// -limit时预览只到第limit个号码为止
+func TestPrintPreviewLimit(t *testing.T) {
+	segments := newSegments(map[string][]string{operatorMobile: {"137"}})
+	for _, tc := range []struct {
+		limit string
+		want  string
+		last  string
+	}{
+		{"3", "Preview of 3 numbers", "13705370002"},
+		{"20", "Preview of 20 numbers", "13705370019"},
+	} {
+		opts := legacyOptions(t, "-preview", "-limit", tc.limit)
+		out := captureStdout(t, func() {
+			if err := printPreview(segments, Config{MiddleCodes: []string{"0537"}}, opts); err != nil {
+				t.Error(err)
+			}
+		})
+		if !strings.Contains(out, tc.want) {
+			t.Errorf("-limit %s: preview does not report %q:\n%s", tc.limit, tc.want, out)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if last := lines[len(lines)-1]; !strings.Contains(last, tc.last) {
+			t.Errorf("-limit %s: preview ends with %q, want %s", tc.limit, last, tc.last)
+		}
+		if strings.Contains(out, "13705379999") {
+			t.Errorf("-limit %s: preview shows numbers past the limit:\n%s", tc.limit, out)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// -print-config的JSON写到标准输出，其余信息写到标准错误（infoOut），便于用jq等工具直接处理
+var printConfigOut io.Writer = os.Stdout
+
+// -print-config输出的生效配置：合并配置文件、命令行参数和默认值之后的结果
+type effectiveConfig struct {
+	MiddleCodes     []string            `json:"middleCodes"`
+	PrefixMiddleMap map[string][]string `json:"prefixMiddleMap,omitempty"`
+	Operators       []string            `json:"operators"`
+	Prefixes        int                 `json:"prefixes"`
+	Layout          effectiveLayout     `json:"layout"`
+	Suffix          effectiveSuffix     `json:"suffix"`
+	Order           string              `json:"order"`
+	Sample          int64               `json:"sample,omitempty"`
+	Seed            *int64              `json:"seed,omitempty"`
+	Weights         string              `json:"weights,omitempty"`
+	Template        string              `json:"template"`
+	OutputBase      int                 `json:"outputBase,omitempty"`
+	SeparatorFuzz   bool                `json:"separatorFuzz,omitempty"`
+	Checksum        string              `json:"checksum,omitempty"`
+	DedupAgainst    string              `json:"dedupAgainst,omitempty"`
+	DeltaAgainst    string              `json:"deltaAgainst,omitempty"`
+	Complement      string              `json:"complement,omitempty"`
+	DedupMode       string              `json:"dedupMode,omitempty"`
+	Formats         []string            `json:"formats"`
+	Outputs         []string            `json:"outputs"`
+	ZipEntries      []string            `json:"zipEntries,omitempty"`
+	Header          bool                `json:"header"`
+	TrailingNewline bool                `json:"trailingNewline"`
+	Log             string              `json:"log,omitempty"`
+}
+
+type effectiveLayout struct {
+	PrefixLen int `json:"prefixLen"`
+	MiddleLen int `json:"middleLen"`
+	SuffixLen int `json:"suffixLen"`
+}
+
+type effectiveSuffix struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Step    int    `json:"step"`
+	Reverse bool   `json:"reverse,omitempty"`
+	PerPair int    `json:"perPair,omitempty"`
+	Shard   string `json:"shard,omitempty"`
+	Digits  string `json:"digits,omitempty"`
+	Lengths string `json:"lengths,omitempty"`
+	// operatorSuffixRanges中按运营商配置的范围，代替上面的start和end
+	Operators map[string]OperatorSuffixRange `json:"operators,omitempty"`
+}
+
+// 以缩进JSON打印生效的配置，不生成任何号码
+func printEffectiveConfig(segments Segments, config Config, opts options) error {
+	formats, err := parseFormats(opts.format)
+	if err != nil {
+		return err
+	}
+	effective := effectiveConfig{
+		MiddleCodes:     config.MiddleCodes,
+		PrefixMiddleMap: config.prefixMiddles(segments),
+		Prefixes:        len(segments.All()),
+		Layout:          effectiveLayout{layout.prefixLen, layout.middleLen, layout.suffixLen},
+		Order:           "sequential",
+		Template:        opts.template,
+		SeparatorFuzz:   opts.sepFuzz,
+		Checksum:        opts.checksum,
+		DedupAgainst:    opts.dedupAgainst,
+		DeltaAgainst:    opts.deltaAgainst,
+		Complement:      opts.complement,
+		Formats:         formats,
+		Header:          opts.header,
+		TrailingNewline: !opts.noTrailingNL,
+		Log:             opts.logFile,
+	}
+	for _, operator := range operatorOrder {
+		if len(segments.Prefixes(operator)) > 0 {
+			effective.Operators = append(effective.Operators, operator)
+		}
+	}
+	start, end, step := suffixBounds(opts)
+	digits, _ := parseSuffixDigits(opts.suffixDigits)
+	effective.Suffix = effectiveSuffix{Start: start, End: end, Step: step, Reverse: opts.reverseSuffix, PerPair: opts.perPair, Shard: opts.shard, Digits: digits, Lengths: opts.suffixLens, Operators: config.OperatorSuffixRanges}
+	if opts.outputBase != 10 {
+		effective.OutputBase = opts.outputBase
+	}
+	if opts.dedupAgainst != "" || opts.deltaAgainst != "" || opts.complement != "" {
+		effective.DedupMode = opts.dedupMode
+	}
+	// 未指定种子时每次运行随机选择，因此只输出显式指定或按日期确定的种子
+	if opts.set["seed"] || opts.dailySeed {
+		seed := randomSeed(opts)
+		effective.Seed = &seed
+	}
+	switch {
+	case opts.sample > 0:
+		effective.Order = "sample"
+		effective.Sample = opts.sample
+		effective.Weights = opts.weights
+	case wantsShuffle(opts):
+		effective.Order = "shuffle"
+	case opts.interleave:
+		effective.Order = "interleave"
+	case opts.sorted:
+		effective.Order = "sorted"
+	}
+	for _, t := range outputTargets(opts, formats) {
+		if opts.zip != "" {
+			// 与createZipEntry一致，条目名取输出文件名
+			effective.ZipEntries = append(effective.ZipEntries, filepath.Base(t.path))
+			continue
+		}
+		// 与createOutput一致，压缩时加上压缩格式的扩展名
+		effective.Outputs = append(effective.Outputs, t.path+compressExts[opts.compress])
+	}
+	if opts.zip != "" {
+		effective.Outputs = []string{opts.zip}
+	}
+
+	data, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %v", err)
+	}
+	fmt.Fprintln(printConfigOut, string(data))
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// 运行run，args为命令行参数；标准错误丢弃，结束后恢复被run修改的全局状态
+func runArgs(t *testing.T, args ...string) int {
+	t.Helper()
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	savedArgs, savedStderr, savedInfo := os.Args, os.Stderr, infoOut
+	savedConfig, savedAutoCreate, savedLayout := configFile, autoCreateConfig, layout
+	defer func() {
+		os.Args, os.Stderr, infoOut = savedArgs, savedStderr, savedInfo
+		configFile, autoCreateConfig, layout = savedConfig, savedAutoCreate, savedLayout
+		runLog = nil
+	}()
+	os.Args, os.Stderr = append([]string{"phonedict"}, args...), devNull
+	return run()
+}
+
+// -print-config只把JSON写到printConfigOut，提示信息改到标准错误，不替换os.Stdout
+func TestPrintConfigStdoutIsJSON(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv(envMiddleCodes, "")
+	var out bytes.Buffer
+	printConfigOut = &out
+	t.Cleanup(func() { printConfigOut = os.Stdout })
+	var code int
+	replaced := false
+	info := captureStdout(t, func() {
+		stdout := os.Stdout
+		code = runArgs(t, "generate", "-print-config", "-middle", "0537")
+		replaced = os.Stdout != stdout
+	})
+	if code != 0 {
+		t.Fatalf("run exited with %d", code)
+	}
+	if replaced {
+		t.Fatal("run replaced os.Stdout")
+	}
+	if info != "" {
+		t.Fatalf("messages were written to stdout: %q", info)
+	}
+	var config map[string]any
+	if err := json.Unmarshal(out.Bytes(), &config); err != nil {
+		t.Fatalf("-print-config output is not JSON: %v\n%s", err, out.String())
+	}
+}
+
+// 按args解析选项并返回-print-config报告的生效配置
+func printedConfig(t *testing.T, args ...string) effectiveConfig {
+	t.Helper()
+	var out bytes.Buffer
+	printConfigOut = &out
+	defer func() { printConfigOut = os.Stdout }()
+	if err := printEffectiveConfig(initDefaultSegments(), Config{MiddleCodes: []string{"0537"}}, legacyOptions(t, args...)); err != nil {
+		t.Fatal(err)
+	}
+	var effective effectiveConfig
+	if err := json.Unmarshal(out.Bytes(), &effective); err != nil {
+		t.Fatal(err)
+	}
+	return effective
+}
+
+// 报告的路径与实际写出的文件一致
+func TestPrintConfigOutputs(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "phonedict.txt"},
+		{[]string{"-format", "txt,csv"}, "phonedict.txt,phonedict.csv"},
+		{[]string{"-compress", "gzip"}, "phonedict.txt.gz"},
+		{[]string{"-compress", "zstd", "-format", "txt,jsonl", "-out", "out/n.txt"}, "out/n.txt.zst,out/n.jsonl.zst"},
+		{[]string{"-out-parallel", "p1.txt,p2.txt"}, "p1.txt,p2.txt"},
+		{[]string{"-out-parallel", "p1.txt,p2.txt", "-compress", "gzip"}, "p1.txt.gz,p2.txt.gz"},
+		{[]string{"-zip", "z.zip", "-format", "txt,csv"}, "z.zip"},
+	} {
+		if got := strings.Join(printedConfig(t, tc.args...).Outputs, ","); got != tc.want {
+			t.Errorf("%v reports outputs %s, want %s", tc.args, got, tc.want)
+		}
+	}
+}
+
+// -zip报告zip文件本身和其中的条目名
+func TestPrintConfigZipEntries(t *testing.T) {
+	effective := printedConfig(t, "-zip", "out/z.zip", "-out", "out/n.txt", "-format", "txt,csv")
+	if got := strings.Join(effective.ZipEntries, ","); got != "n.txt,n.csv" {
+		t.Errorf("zip entries %s, want n.txt,n.csv", got)
+	}
+}
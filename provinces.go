@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 省级行政区及其地级市的长途区号，按本工具的约定写成4位中间码：三位区号末尾补0（010 -> 0100），
+// 四位区号原样使用（0537）。只覆盖各地级市的主区号
+var provinceMiddleCodes = []struct {
+	name  string
+	cn    string
+	codes []string
+}{
+	{"Beijing", "北京", []string{"0100"}},
+	{"Tianjin", "天津", []string{"0220"}},
+	{"Shanghai", "上海", []string{"0210"}},
+	{"Chongqing", "重庆", []string{"0230"}},
+	{"Hebei", "河北", []string{"0310", "0311", "0312", "0313", "0314", "0315", "0316", "0317", "0318", "0319", "0335"}},
+	{"Shanxi", "山西", []string{"0349", "0350", "0351", "0352", "0353", "0354", "0355", "0356", "0357", "0358", "0359"}},
+	{"Inner Mongolia", "内蒙古", []string{"0470", "0471", "0472", "0473", "0474", "0475", "0476", "0477", "0478", "0479", "0482", "0483"}},
+	{"Liaoning", "辽宁", []string{"0240", "0410", "0411", "0412", "0413", "0414", "0415", "0416", "0417", "0418", "0419", "0421", "0427", "0429"}},
+	{"Jilin", "吉林", []string{"0431", "0432", "0433", "0434", "0435", "0436", "0437", "0438", "0439"}},
+	{"Heilongjiang", "黑龙江", []string{"0451", "0452", "0453", "0454", "0455", "0456", "0457", "0458", "0459", "0464", "0467", "0468", "0469"}},
+	{"Jiangsu", "江苏", []string{"0250", "0510", "0511", "0512", "0513", "0514", "0515", "0516", "0517", "0518", "0519", "0523", "0527"}},
+	{"Zhejiang", "浙江", []string{"0570", "0571", "0572", "0573", "0574", "0575", "0576", "0577", "0578", "0579", "0580"}},
+	{"Anhui", "安徽", []string{"0550", "0551", "0552", "0553", "0554", "0555", "0556", "0557", "0558", "0559", "0561", "0562", "0563", "0564", "0566"}},
+	{"Fujian", "福建", []string{"0591", "0592", "0593", "0594", "0595", "0596", "0597", "0598", "0599"}},
+	{"Jiangxi", "江西", []string{"0701", "0790", "0791", "0792", "0793", "0794", "0795", "0796", "0797", "0798", "0799"}},
+	{"Shandong", "山东", []string{"0530", "0531", "0532", "0533", "0534", "0535", "0536", "0537", "0538", "0539", "0543", "0546", "0631", "0632", "0633", "0634", "0635"}},
+	{"Henan", "河南", []string{"0370", "0371", "0372", "0373", "0374", "0375", "0376", "0377", "0378", "0379", "0391", "0392", "0393", "0394", "0395", "0396", "0398"}},
+	{"Hubei", "湖北", []string{"0270", "0710", "0711", "0712", "0713", "0714", "0715", "0716", "0717", "0718", "0719", "0722", "0724", "0728"}},
+	{"Hunan", "湖南", []string{"0730", "0731", "0734", "0735", "0736", "0737", "0738", "0739", "0743", "0744", "0745", "0746"}},
+	{"Guangdong", "广东", []string{"0200", "0660", "0662", "0663", "0668", "0750", "0751", "0752", "0753", "0754", "0755", "0756", "0757", "0758", "0759", "0760", "0762", "0763", "0766", "0768", "0769"}},
+	{"Guangxi", "广西", []string{"0770", "0771", "0772", "0773", "0774", "0775", "0776", "0777", "0778", "0779"}},
+	{"Hainan", "海南", []string{"0898"}},
+	{"Sichuan", "四川", []string{"0280", "0812", "0813", "0816", "0817", "0818", "0825", "0826", "0827", "0830", "0831", "0832", "0833", "0834", "0835", "0836", "0837", "0838", "0839"}},
+	{"Guizhou", "贵州", []string{"0851", "0852", "0853", "0854", "0855", "0856", "0857", "0858", "0859"}},
+	{"Yunnan", "云南", []string{"0691", "0692", "0870", "0871", "0872", "0873", "0874", "0875", "0876", "0877", "0878", "0879", "0883", "0886", "0887", "0888"}},
+	{"Tibet", "西藏", []string{"0891", "0892", "0893", "0894", "0895", "0896", "0897"}},
+	{"Shaanxi", "陕西", []string{"0290", "0911", "0912", "0913", "0914", "0915", "0916", "0917", "0919"}},
+	{"Gansu", "甘肃", []string{"0930", "0931", "0932", "0933", "0934", "0935", "0936", "0937", "0938", "0939", "0941", "0943"}},
+	{"Qinghai", "青海", []string{"0970", "0971", "0972", "0973", "0974", "0975", "0976", "0977"}},
+	{"Ningxia", "宁夏", []string{"0951", "0952", "0953", "0954", "0955"}},
+	{"Xinjiang", "新疆", []string{"0901", "0902", "0903", "0906", "0908", "0909", "0990", "0991", "0992", "0993", "0994", "0995", "0996", "0997", "0998", "0999"}},
+}
+
+// 解析逗号分隔的省份名（英文不区分大小写、可省略空格，或中文名），返回去重后的中间码及每个中间码所属的省份
+func parseProvinces(input string) ([]string, map[string]string, error) {
+	if layout.middleLen != 4 {
+		return nil, nil, fmt.Errorf("-province needs 4-digit middle codes, got -middle-len %d", layout.middleLen)
+	}
+	var middleCodes []string
+	regions := make(map[string]string)
+	for _, name := range strings.Split(input, ",") {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), " ", ""))
+		if key == "" {
+			continue
+		}
+		found := false
+		for _, p := range provinceMiddleCodes {
+			if key != strings.ToLower(strings.ReplaceAll(p.name, " ", "")) && key != p.cn {
+				continue
+			}
+			found = true
+			for _, code := range p.codes {
+				if _, seen := regions[code]; !seen {
+					middleCodes = append(middleCodes, code)
+					regions[code] = p.name
+				}
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("unknown province %q (known provinces: %s)", strings.TrimSpace(name), strings.Join(provinceNames(), ", "))
+		}
+	}
+	if len(middleCodes) == 0 {
+		return nil, nil, fmt.Errorf("no province given")
+	}
+	return middleCodes, regions, nil
+}
+
+func provinceNames() []string {
+	names := make([]string, len(provinceMiddleCodes))
+	for i, p := range provinceMiddleCodes {
+		names[i] = p.name
+	}
+	return names
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+)
+
+// -format=ranges每个组合只输出一行连续的号码范围，不能与使尾号不连续或改变号码本身的参数同时使用
+var rangesIncompatibleFlags = []string{
+	"suffix-step", "shard", "per-pair", "block-file",
+	"limit", "sample", "weights", "shuffle", "seed", "daily-seed", "interleave",
+	"checksum", "blocklist-regex", "dedup-against", "delta-against", "complement", "seed-file", "template", "separator-fuzz", "output-base", "header", "index", "grouped", "no-prefix", "suffix-digits",
+}
+
+func checkRangesOptions(formats []string, opts options) error {
+	hasRanges := false
+	for _, format := range formats {
+		hasRanges = hasRanges || format == "ranges"
+	}
+	if !hasRanges {
+		return nil
+	}
+	if len(formats) > 1 {
+		return fmt.Errorf("-format=ranges cannot be combined with other formats")
+	}
+	for _, name := range rangesIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-format=ranges cannot be used with -%s (each line describes a contiguous suffix range)", name)
+		}
+	}
+	return nil
+}
+
+// 组合对应的范围行，如137-0537 → 13705370000-13705379999
+func rangeLine(prefix, middle string, start, end, suffixLen int) string {
+	return fmt.Sprintf("%s-%s → %s%s%0*d-%s%s%0*d", prefix, middle, prefix, middle, suffixLen, start, prefix, middle, suffixLen, end)
+}
+
+// 每个号段+中间码组合输出一行号码范围，用于说明覆盖范围而不输出每个号码，返回行数
+func generateRanges(prefixes, middleCodes []string, allowed map[string][]string, opts options) (int64, error) {
+	s := newGenConfig(spaceOptions(opts, allowed, nil)).buildSpace(prefixes, middleCodes)
+	start, end, _ := suffixBounds(opts)
+	fmt.Fprintf(infoOut, "\n📱 Range generation plan:\n")
+	if opts.suffixRanges != nil {
+		fmt.Fprintf(infoOut, "Ranges: %d | Numbers covered: %d (operatorSuffixRanges applied)\n", len(s.blocks), s.total)
+	} else {
+		fmt.Fprintf(infoOut, "Ranges: %d | Numbers covered: %d (%d per range)\n", len(s.blocks), s.total, end-start+1)
+	}
+
+	f, err := createOutput(opts.out, "ranges", opts.compress, opts.writeRetries)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(f.w)
+	for _, b := range s.blocks {
+		// 配置了operatorSuffixRanges的号段使用各自的范围
+		first, last := start, end
+		if r, ok := opts.suffixRanges[b.prefix]; ok {
+			first, last = r.start, r.end
+		}
+		if _, err := w.WriteString(rangeLine(b.prefix, b.middle, first, last, layout.suffixLen) + "\n"); err != nil {
+			f.abort()
+			return 0, fmt.Errorf("failed to write to file: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.abort()
+		return 0, fmt.Errorf("failed to write to file: %v", err)
+	}
+	if err := f.commit(); err != nil {
+		f.abort()
+		return 0, err
+	}
+	logf("✅ Range file completed! Ranges written: %d\n", len(s.blocks))
+	logf("Output (ranges): %s\n", f.path)
+	return int64(len(s.blocks)), nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRangeLine(t *testing.T) {
+	if got, want := rangeLine("137", "0537", 0, 9999, 4), "137-0537 → 13705370000-13705379999"; got != want {
+		t.Fatalf("rangeLine = %q, want %q", got, want)
+	}
+}
+
+// 每个组合一行，端点为该组合的第一个和最后一个号码
+func TestGenerateRangesEndpoints(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		ranges map[string]suffixBlockRange
+		want   []string
+	}{
+		{"full", nil, nil, []string{
+			"137-0537 → 13705370000-13705379999",
+			"137-0100 → 13701000000-13701009999",
+		}},
+		{"suffix bounds", []string{"-suffix-start", "100", "-suffix-end", "250"}, nil, []string{
+			"137-0537 → 13705370100-13705370250",
+			"137-0100 → 13701000100-13701000250",
+		}},
+		{"operator suffix range", nil, map[string]suffixBlockRange{"137": {5000, 5999}}, []string{
+			"137-0537 → 13705375000-13705375999",
+			"137-0100 → 13701005000-13701005999",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			opts := legacyOptions(t, append([]string{"-format", "ranges", "-out", "ranges.txt"}, tc.args...)...)
+			opts.suffixRanges = tc.ranges
+			var n int64
+			var err error
+			captureStdout(t, func() {
+				n, err = generateRanges([]string{"137"}, []string{"0537", "0100"}, nil, opts)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile("ranges.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if n != int64(len(tc.want)) || strings.Join(lines, "\n") != strings.Join(tc.want, "\n") {
+				t.Fatalf("got %d ranges:\n%s\nwant:\n%s", n, data, strings.Join(tc.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"sync"
+)
+
+// NumberReader 随读取进度生成号码的io.Reader，内容与Generate写出的相同（每行一个号码），
+// 可直接交给io.Copy、http.Post或bufio.Scanner。生成在第一次Read时开始，只在读取方取走数据后
+// 继续，内存中最多保留一个写缓冲区的号码。提前停止读取时应调用Close结束生成
+type NumberReader struct {
+	prefixes    []string
+	middleCodes []string
+	opts        []Option
+	once        sync.Once
+	pr          *io.PipeReader
+}
+
+// NewNumberReader 返回按opts生成号码的NumberReader，参数与Generate相同
+func NewNumberReader(prefixes, middleCodes []string, opts ...Option) *NumberReader {
+	return &NumberReader{prefixes: prefixes, middleCodes: middleCodes, opts: opts}
+}
+
+// 生成协程写入管道，读取方读得慢时写入阻塞，生成随之暂停；生成出错时Read返回该错误
+func (r *NumberReader) start() {
+	pr, pw := io.Pipe()
+	r.pr = pr
+	go func() {
+		_, err := Generate(pw, r.prefixes, r.middleCodes, r.opts...)
+		pw.CloseWithError(err)
+	}()
+}
+
+func (r *NumberReader) Read(p []byte) (int, error) {
+	r.once.Do(r.start)
+	return r.pr.Read(p)
+}
+
+// Close 停止生成，之后的Read返回io.ErrClosedPipe；尚未开始读取时不再启动生成
+func (r *NumberReader) Close() error {
+	r.once.Do(func() {
+		r.pr, _ = io.Pipe()
+	})
+	return r.pr.Close()
+}
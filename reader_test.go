@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// 通过NumberReader读出的内容与Generate写出的相同，按行计数等于号码数
+func TestNumberReaderLines(t *testing.T) {
+	prefixes, middleCodes := []string{"137", "138", "139"}, []string{"0537", "0100"}
+	var want bytes.Buffer
+	if _, err := Generate(&want, prefixes, middleCodes); err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(NewNumberReader(prefixes, middleCodes))
+	var got bytes.Buffer
+	lines := 0
+	for scanner.Scan() {
+		got.WriteString(scanner.Text() + "\n")
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines != 60000 {
+		t.Fatalf("read %d lines, want 60000", lines)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Fatal("NumberReader output differs from Generate")
+	}
+}
+
+func TestNumberReaderClose(t *testing.T) {
+	r := NewNumberReader([]string{"137"}, []string{"0537"})
+	buf := make([]byte, 12)
+	if _, err := io.ReadFull(r, buf); err != nil || string(buf) != "13705370000\n" {
+		t.Fatalf("first line %q, %v", buf, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("Read after Close returned %v, want io.ErrClosedPipe", err)
+	}
+
+	// 从未读取过的reader关闭后不会开始生成
+	unread := NewNumberReader([]string{"137"}, []string{"0537"})
+	if err := unread.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unread.Read(buf); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("Read after Close returned %v, want io.ErrClosedPipe", err)
+	}
+}
+
+// 生成出错时Read返回该错误
+func TestNumberReaderError(t *testing.T) {
+	_, err := io.Copy(io.Discard, NewNumberReader([]string{"137"}, []string{"0537"}, WithSuffixLen(0)))
+	if err == nil {
+		t.Fatal("an invalid suffix length was not reported")
+	}
+}
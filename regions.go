@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 从CSV读取中间码及地区名（格式 middle,region），首行为表头时自动跳过，
+// 返回按文件顺序去重后的中间码和中间码到地区名的映射
+func loadMiddleCSV(path string) ([]string, map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	validRegex := layout.middleRegex()
+
+	var middleCodes []string
+	regions := make(map[string]string)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		line, _ := reader.FieldPos(0)
+		code := strings.TrimSpace(record[0])
+		if code == "" && len(record) == 1 {
+			continue
+		}
+		if !validRegex.MatchString(code) {
+			if first {
+				continue // 表头
+			}
+			return nil, nil, fmt.Errorf("%s line %d: invalid middle code %q (must be %d-digit number)", path, line, code, layout.middleLen)
+		}
+		if _, seen := regions[code]; seen {
+			continue
+		}
+		region := ""
+		if len(record) > 1 {
+			region = strings.TrimSpace(record[1])
+		}
+		middleCodes = append(middleCodes, code)
+		regions[code] = region
+	}
+	if len(middleCodes) == 0 {
+		return nil, nil, fmt.Errorf("no middle codes found in %s", path)
+	}
+	return middleCodes, regions, nil
+}
+
+// 按地区汇总打印中间码，地区按首次出现的顺序排列
+func printRegionSummary(middleCodes []string, regions map[string]string) {
+	var order []string
+	byRegion := make(map[string][]string)
+	for _, code := range middleCodes {
+		region := regions[code]
+		if region == "" {
+			region = "(unknown region)"
+		}
+		if _, ok := byRegion[region]; !ok {
+			order = append(order, region)
+		}
+		byRegion[region] = append(byRegion[region], code)
+	}
+	fmt.Fprintf(infoOut, "Region summary (%d regions):\n", len(order))
+	for _, region := range order {
+		fmt.Fprintf(infoOut, "  %s: %d middle code(s) %v\n", region, len(byRegion[region]), byRegion[region])
+	}
+}
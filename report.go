@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// -report：生成结束后写入每个号段实际生成数量的CSV（prefix,operator,count），最后一行为合计
+func writePrefixReport(path string, prefixes []string, operators map[string]string, counts map[string]int64) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "prefix,operator,count")
+	var total int64
+	for _, prefix := range prefixes {
+		fmt.Fprintf(w, "%s,%s,%d\n", prefix, csvField(operatorLabel(operators[prefix])), counts[prefix])
+		total += counts[prefix]
+	}
+	fmt.Fprintf(w, "total,,%d\n", total)
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write to %s: %v", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write to %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"syscall"
+	"time"
+)
+
+// 第一次重试前的等待时间，之后每次翻倍
+const writeRetryBackoff = 100 * time.Millisecond
+
+// 写入出错时按-write-retries重试暂时性错误（网络文件系统偶发的超时等），
+// 部分写入时只重试剩余的数据
+type retryWriter struct {
+	w       io.Writer
+	name    string
+	retries int
+}
+
+func (r *retryWriter) Write(p []byte) (int, error) {
+	written := 0
+	for attempt := 0; ; attempt++ {
+		n, err := r.w.Write(p[written:])
+		written += n
+		if err == nil {
+			return written, nil
+		}
+		if attempt >= r.retries || !retryableWriteError(err) {
+			return written, err
+		}
+		delay := writeRetryBackoff << attempt
+		logf("⚠️ Writing %s failed (%v), retry %d/%d in %s\n", r.name, err, attempt+1, r.retries, delay)
+		time.Sleep(delay)
+	}
+}
+
+// 超时以及EAGAIN、EINTR、EIO、EBUSY视为暂时性错误；磁盘已满、只读文件系统等其余错误重试也无济于事
+func retryableWriteError(err error) bool {
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return true
+	}
+	for _, errno := range []syscall.Errno{syscall.EAGAIN, syscall.EINTR, syscall.EIO, syscall.EBUSY, syscall.ETIMEDOUT} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
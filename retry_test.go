@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+	"testing"
+)
+
+// 前failures次写入只写一半数据并返回err，之后正常写入
+type flakyWriter struct {
+	buf      bytes.Buffer
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *flakyWriter) Write(p []byte) (int, error) {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		n, _ := f.buf.Write(p[:len(p)/2])
+		return n, f.err
+	}
+	return f.buf.Write(p)
+}
+
+func TestRetryWriter(t *testing.T) {
+	data := []byte("13705370000\n13705370001\n")
+	for _, tc := range []struct {
+		name     string
+		failures int
+		err      error
+		retries  int
+		wantErr  bool
+		calls    int
+	}{
+		{"recovers after transient errors", 2, syscall.EIO, 3, false, 3},
+		{"gives up after the retries", 3, syscall.EAGAIN, 2, true, 3},
+		{"does not retry a full disk", 1, syscall.ENOSPC, 3, true, 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			flaky := &flakyWriter{failures: tc.failures, err: tc.err}
+			w := &retryWriter{w: flaky, name: "test", retries: tc.retries}
+			n, err := w.Write(data)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Write returned %v, want error %v", err, tc.wantErr)
+			}
+			if err != nil && !errors.Is(err, tc.err) {
+				t.Fatalf("Write returned %v, want %v", err, tc.err)
+			}
+			if flaky.calls != tc.calls {
+				t.Fatalf("underlying writer called %d times, want %d", flaky.calls, tc.calls)
+			}
+			if n != flaky.buf.Len() {
+				t.Fatalf("Write reported %d bytes, %d were written", n, flaky.buf.Len())
+			}
+			if !tc.wantErr && !bytes.Equal(flaky.buf.Bytes(), data) {
+				t.Fatalf("wrote %q, want %q", flaky.buf.Bytes(), data)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// -log指定的运行日志，为nil时只输出到控制台
+var runLog *log.Logger
+
+// 提示信息（计划、进度、汇总等）的输出位置。-emit-rate和-print-config时改为标准错误，
+// 标准输出只留给号码或JSON
+var infoOut io.Writer = os.Stdout
+
+// 提示信息所在的文件，infoOut不是文件时为nil
+func infoFile() *os.File {
+	f, _ := infoOut.(*os.File)
+	return f
+}
+
+// 以追加方式打开运行日志，返回的文件由调用方关闭
+func openRunLog(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file %s: %v", path, err)
+	}
+	runLog = log.New(file, "", log.LstdFlags)
+	return file, nil
+}
+
+// 只写入运行日志（带时间戳），去掉首尾的空行
+func writeLog(format string, args ...any) {
+	if runLog == nil {
+		return
+	}
+	runLog.Print(strings.TrimSpace(fmt.Sprintf(format, args...)))
+}
+
+// 输出到控制台，同时写入运行日志
+func logf(format string, args ...any) {
+	fmt.Fprintf(infoOut, format, args...)
+	writeLog(format, args...)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// 解析s3://bucket/key，对象键不能为空或以/结尾
+func parseS3URL(url string) (bucket, key string, err error) {
+	rest, _ := strings.CutPrefix(url, "s3://")
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("invalid S3 output %s (must be s3://bucket/key)", url)
+	}
+	return bucket, key, nil
+}
+
+// 上传到S3：凭证和区域按AWS默认方式查找（环境变量、共享配置文件、实例角色等），
+// 写入的数据按分段上传边生成边发送，失败时已上传的分段会被清理。
+// 设置了AWS_ENDPOINT_URL或AWS_ENDPOINT_URL_S3（如MinIO）时按路径形式访问存储桶
+func startS3Upload(url string) (*upload, error) {
+	bucket, key, err := parseS3URL(url)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration for %s: %v", url, err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured for %s, set AWS_REGION or a region in the AWS profile", url)
+	}
+	// 在生成之前检查凭证，避免生成了一部分号码才发现无法上传
+	if _, err := cfg.Credentials.Retrieve(ctx); err != nil {
+		return nil, fmt.Errorf("no AWS credentials found for %s (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or AWS_PROFILE): %v", url, err)
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = os.Getenv("AWS_ENDPOINT_URL") != "" || os.Getenv("AWS_ENDPOINT_URL_S3") != ""
+	})
+
+	pr, pw := io.Pipe()
+	u := &upload{url: url, pw: pw, done: make(chan struct{})}
+	u.start(pr, func() error {
+		_, err := manager.NewUploader(client).Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+			Body:   pr,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to upload to %s: %v", url, err)
+		}
+		return nil
+	})
+	return u, nil
+}
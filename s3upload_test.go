@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 只实现PutObject和分段上传的S3服务，记录上传完成的对象
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	parts   map[string]map[int][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+	body, err := readS3Body(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.parts[key] = make(map[int][]byte)
+		fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>b</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, key, key)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		f.parts[key][n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"part%d"`, n))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var numbers []int
+		for n := range f.parts[key] {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var object []byte
+		for _, n := range numbers {
+			object = append(object, f.parts[key][n]...)
+		}
+		f.objects[key] = object
+		fmt.Fprintf(w, `<CompleteMultipartUploadResult><Key>%s</Key><ETag>"done"</ETag></CompleteMultipartUploadResult>`, key)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.parts, key)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		w.Header().Set("ETag", `"object"`)
+	default:
+		http.Error(w, "unsupported request", http.StatusNotImplemented)
+	}
+}
+
+// 读取请求体，按需解开aws-chunked编码
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.Contains(r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return io.ReadAll(r.Body)
+	}
+	var body []byte
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		n, err := strconv.ParseInt(size, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return body, nil
+		}
+		chunk := make([]byte, n+2)
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, err
+		}
+		body = append(body, chunk[:n]...)
+	}
+}
+
+// 把AWS的凭证、区域和地址都指向测试服务，不读取本机的AWS配置
+func useFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+	fake := &fakeS3{objects: make(map[string][]byte), parts: make(map[string]map[int][]byte)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	return fake
+}
+
+func TestS3Upload(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		middleCodes []string
+	}{
+		{"single part", []string{"0537"}},
+		{"multipart", []string{"0537", "0100", "0210", "0755", "0531", "0532"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := useFakeS3(t)
+			f, err := createOutput("s3://bucket/dict/phonedict.txt", "txt", "none", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want bytes.Buffer
+			if _, err := Generate(io.MultiWriter(f.w, &want), []string{"137", "138", "139", "150", "151", "152", "157", "158", "159", "188"}, tc.middleCodes); err != nil {
+				f.abort()
+				t.Fatal(err)
+			}
+			if err := f.commit(); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := fake.objects["bucket/dict/phonedict.txt"]
+			if !ok {
+				t.Fatalf("object was not uploaded, have %d objects", len(fake.objects))
+			}
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Fatalf("uploaded %d bytes, want %d", len(got), want.Len())
+			}
+		})
+	}
+}
+
+func TestS3UploadAbort(t *testing.T) {
+	fake := useFakeS3(t)
+	f, err := createOutput("s3://bucket/phonedict.txt", "txt", "none", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Generate(f.w, []string{"137"}, []string{"0537"}); err != nil {
+		t.Fatal(err)
+	}
+	f.abort()
+	if _, ok := fake.objects["bucket/phonedict.txt"]; ok {
+		t.Fatal("aborted output was uploaded")
+	}
+}
+
+func TestParseS3URL(t *testing.T) {
+	bucket, key, err := parseS3URL("s3://bucket/dir/phonedict.txt")
+	if err != nil || bucket != "bucket" || key != "dir/phonedict.txt" {
+		t.Fatalf("got %q %q %v", bucket, key, err)
+	}
+	for _, url := range []string{"s3://", "s3://bucket", "s3://bucket/", "s3:///key", "s3://bucket/dir/"} {
+		if _, _, err := parseS3URL(url); err == nil {
+			t.Errorf("parseS3URL(%q) accepted", url)
+		}
+	}
+}
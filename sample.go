@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// WithSample 只随机抽取n个不重复的号码，按抽取顺序输出，相同seed得到相同结果
+func WithSample(n, seed int64) Option {
+	return func(c *genConfig) {
+		c.sample = n
+		c.seed = seed
+	}
+}
+
+// WithPrefixWeights 为抽样设置各号段的权重（无需归一化），每次抽取先按权重选号段，
+// 再从该号段中不重复地随机取号；号段取尽后其余抽取按剩余权重分配。未设置时均匀抽样
+func WithPrefixWeights(weights map[string]float64) Option {
+	return func(c *genConfig) {
+		c.prefixWeights = weights
+	}
+}
+
+func walkSampled(s *space, c genConfig, visit visitFunc) error {
+	if c.prefixWeights == nil {
+		if uint64(c.sample) > s.total {
+			return fmt.Errorf("sample size %d exceeds the %d numbers available", c.sample, s.total)
+		}
+		// 均匀抽样：取全局随机置换的前n个
+		perm := newPermutation(s.total, c.seed)
+		for i := uint64(0); i < uint64(c.sample); i++ {
+			if err := visit(s.at(perm.at(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	prefixes, spaces := s.byPrefix()
+	weights := make([]float64, len(prefixes))
+	available := uint64(0)
+	for i, prefix := range prefixes {
+		if w := c.prefixWeights[prefix]; w > 0 && spaces[i].total > 0 {
+			weights[i] = w
+			available += spaces[i].total
+		}
+	}
+	if uint64(c.sample) > available {
+		return fmt.Errorf("sample size %d exceeds the %d numbers available under the given weights", c.sample, available)
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(c.seed), 0))
+	perms := make([]*permutation, len(prefixes))
+	cursors := make([]uint64, len(prefixes))
+	for n := int64(0); n < c.sample; n++ {
+		sum := 0.0
+		for _, w := range weights {
+			sum += w
+		}
+		pick, r := 0, rng.Float64()*sum
+		for i, w := range weights {
+			if w == 0 {
+				continue
+			}
+			pick = i
+			if r < w {
+				break
+			}
+			r -= w
+		}
+		if perms[pick] == nil {
+			perms[pick] = newPermutation(spaces[pick].total, c.seed+int64(pick)+1)
+		}
+		idx := perms[pick].at(cursors[pick])
+		cursors[pick]++
+		if cursors[pick] == spaces[pick].total {
+			weights[pick] = 0
+		}
+		if err := visit(spaces[pick].at(idx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
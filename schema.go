@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// -config-schema输出的JSON Schema：字段和类型由反射Config的json标签得到，新增字段会自动出现；
+// 说明和反射推断不出的约束写在configFieldSchemas中，按json字段名合并进去
+func configSchema() map[string]any {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	delete(schema, "required") // 所有字段都可以省略，缺少中间码时由运行时报错
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "phonedict config"
+	properties := schema["properties"].(map[string]any)
+	for name, extra := range configFieldSchemas() {
+		if property, ok := properties[name].(map[string]any); ok {
+			mergeSchema(property, extra)
+		}
+	}
+	return schema
+}
+
+// 各字段的说明和约束，号段、中间码和尾号的长度取当前的-prefix-len/-middle-len/-suffix-len
+func configFieldSchemas() map[string]map[string]any {
+	maxSuffix := pow10(layout.suffixLen) - 1
+	codes := map[string]any{
+		"description": fmt.Sprintf("%d-digit codes, wildcards such as 05* or 0?37, or inclusive ranges such as 0100-0120", layout.middleLen),
+		"pattern":     "^[0-9*?]+(-[0-9]+)?$",
+	}
+	return map[string]map[string]any{
+		"middleCodes": {
+			"description": "middle codes used when no -middle is given",
+			"items":       codes,
+		},
+		"defaultMiddleCodes": {
+			"description": "middle codes used when middleCodes is empty; -default-middle takes precedence",
+			"items":       codes,
+		},
+		"prefixMiddleMap": {
+			"description":          "restricts the listed prefixes to these middle codes; the codes must also be in middleCodes",
+			"propertyNames":        map[string]any{"pattern": fmt.Sprintf("^[0-9]{%d}$", layout.prefixLen)},
+			"additionalProperties": map[string]any{"items": map[string]any{"pattern": fmt.Sprintf("^[0-9]{%d}$", layout.middleLen)}},
+		},
+		"campaigns": {
+			"description":          "named middle code groups, each generated into its own file; replaces middleCodes when present",
+			"propertyNames":        map[string]any{"pattern": campaignNameRegex.String()},
+			"additionalProperties": map[string]any{"items": codes},
+		},
+		"operatorSuffixRanges": {
+			"description":   "suffix range (inclusive) per operator; operators that are not listed use -suffix-start and -suffix-end",
+			"propertyNames": map[string]any{"enum": operatorOrder},
+			"additionalProperties": map[string]any{"properties": map[string]any{
+				"start": map[string]any{"minimum": 0, "maximum": maxSuffix},
+				"end":   map[string]any{"minimum": 0, "maximum": maxSuffix},
+			}},
+		},
+	}
+}
+
+// 按Go类型推断的schema；结构体按json标签列出字段，不带omitempty的字段为必填
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]any)
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type)
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
+
+// 把extra递归合并进schema，同名的子schema逐层合并，其余值直接覆盖
+func mergeSchema(schema, extra map[string]any) {
+	for key, value := range extra {
+		sub, ok := value.(map[string]any)
+		if existing, isMap := schema[key].(map[string]any); ok && isMap {
+			mergeSchema(existing, sub)
+			continue
+		}
+		schema[key] = value
+	}
+}
+
+func printConfigSchema() error {
+	data, err := json.MarshalIndent(configSchema(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the config schema: %v", err)
+	}
+	fmt.Fprintln(infoOut, string(data))
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 读取-seed-file中的已知号码（跳过空行和#注释行），格式不对的行打印警告后跳过，重复的号码只保留一次
+func loadSeedNumbers(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+
+	numberRegex := regexp.MustCompile(fmt.Sprintf(`^\d{%d}$`, layout.totalLen()))
+	seen := make(map[string]bool)
+	var seeds []string
+	lineNo := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !numberRegex.MatchString(line) {
+			logf("Warning: line %d of %s is not a %d-digit number, skipped: %q\n", lineNo, path, layout.totalLen(), line)
+			continue
+		}
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		seeds = append(seeds, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	return seeds, nil
+}
+
+// 把已知号码写在生成的号码之前，按各输出的格式和-output-base编码，序号从indexStart开始
+func writeSeedNumbers(outputs []Output, seeds []string, base int, indexStart int64) error {
+	middleEnd := layout.prefixLen + layout.middleLen
+	for i, number := range seeds {
+		suffix, _ := strconv.Atoi(number[middleEnd:])
+		record := Record{Number: number, Prefix: number[:layout.prefixLen], Middle: number[layout.prefixLen:middleEnd], Suffix: suffix, Index: indexStart + int64(i)}
+		if base != 0 && base != 10 {
+			record.Number = formatBase(number, base)
+		}
+		for _, out := range outputs {
+			if out.Binary {
+				if _, err := out.W.Write(appendBinaryNumber(nil, record.Number)); err != nil {
+					return fmt.Errorf("failed to write to file: %v", err)
+				}
+				continue
+			}
+			line := record.Number
+			if out.Encode != nil {
+				line = out.Encode(record)
+			}
+			if _, err := fmt.Fprintln(out.W, line); err != nil {
+				return fmt.Errorf("failed to write to file: %v", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// 启动HTTP服务，GET /generate?middle=0537,0100&operators=mobile&limit=1000 以分块传输流式返回号码。
+// 服务没有任何认证，只应在可信网络中使用
+func serveHTTP(addr string, segments Segments) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/generate", func(w http.ResponseWriter, r *http.Request) {
+		handleGenerate(w, r, segments)
+	})
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(infoOut, "🌐 Serving on %s, e.g. GET /generate?middle=0537&operators=mobile\n", addr)
+	fmt.Fprintln(infoOut, "⚠️ The server has no authentication, only expose it on trusted networks")
+	return server.ListenAndServe()
+}
+
+// 每个请求最多返回的号码数；覆盖范围更大的请求必须用limit参数截取
+const serverMaxNumbers = 10_000_000
+
+// 每次写出后立即把数据推给客户端
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.flusher.Flush()
+	return n, err
+}
+
+func handleGenerate(w http.ResponseWriter, r *http.Request, segments Segments) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	middleCodes, err := parseMiddleCodes(query.Get("middle"))
+	if err != nil {
+		http.Error(w, "invalid middle: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if value := query.Get("operators"); value != "" {
+		operators, err := parseOperators(value)
+		if err != nil {
+			http.Error(w, "invalid operators: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		segments = segments.Only(operators)
+	}
+	limit := int64(serverMaxNumbers)
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 1 || n > serverMaxNumbers {
+			http.Error(w, fmt.Sprintf("invalid limit %q (must be 1 to %d)", value, serverMaxNumbers), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	} else if total := EstimateCount(segments.All(), middleCodes, 0, pow10(layout.suffixLen)-1, 1); total > serverMaxNumbers {
+		// 通配符很容易展开成上百亿个号码，不带limit时拒绝而不是悄悄截断
+		http.Error(w, fmt.Sprintf("the request covers %d numbers, more than the server maximum of %d; narrow middle or operators, or pass limit", total, serverMaxNumbers),
+			http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// 读取方取走数据后才继续生成，客户端断开时Copy出错，Close结束生成
+	var count atomic.Int64
+	numbers := NewNumberReader(segments.All(), middleCodes,
+		WithSuffixLen(layout.suffixLen),
+		WithLimit(limit),
+		WithProgress(func(done, total int64) {
+			count.Store(done)
+		}))
+	defer numbers.Close()
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.Copy(flushWriter{w, flusher}, numbers); err != nil {
+		log.Printf("%s %s: stream aborted after %d numbers: %v", r.RemoteAddr, r.URL, count.Load(), err)
+		return
+	}
+	log.Printf("%s %s: streamed %d numbers", r.RemoteAddr, r.URL, count.Load())
+}
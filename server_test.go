@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHandleGenerate(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	segments := initDefaultSegments()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handleGenerate(w, r, segments)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/generate?middle=0537&operators=mobile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+	mobile := segments.Only([]string{"mobile"}).All()
+	scanner := bufio.NewScanner(resp.Body)
+	lines := 0
+	for scanner.Scan() {
+		if want := mobile[lines/10000] + "0537"; scanner.Text()[:7] != want {
+			t.Fatalf("line %d is %q, want prefix %s", lines, scanner.Text(), want)
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines != len(mobile)*10000 {
+		t.Fatalf("got %d lines, want %d", lines, len(mobile)*10000)
+	}
+
+	for _, tc := range []struct {
+		method, query string
+		status        int
+	}{
+		{http.MethodGet, "?middle=05x7", http.StatusBadRequest},
+		{http.MethodGet, "?middle=0537&operators=nobody", http.StatusBadRequest},
+		{http.MethodPost, "?middle=0537", http.StatusMethodNotAllowed},
+	} {
+		req, _ := http.NewRequest(tc.method, server.URL+"/generate"+tc.query, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s returned %d, want %d", tc.method, tc.query, resp.StatusCode, tc.status)
+		}
+	}
+}
+
+// 响应写入后立即刷新；不支持刷新的ResponseWriter被拒绝
+func TestHandleGenerateFlushes(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	rec := httptest.NewRecorder()
+	handleGenerate(rec, httptest.NewRequest(http.MethodGet, "/generate?middle=0537&operators=mobile&limit=5", nil), initDefaultSegments())
+	if rec.Code != http.StatusOK || !rec.Flushed {
+		t.Fatalf("got status %d, flushed %v", rec.Code, rec.Flushed)
+	}
+	if want := "13405370000\n13405370001\n13405370002\n13405370003\n13405370004\n"; rec.Body.String() != want {
+		t.Fatalf("got %q, want %q", rec.Body.String(), want)
+	}
+
+	plain := &plainResponseWriter{header: http.Header{}}
+	handleGenerate(plain, httptest.NewRequest(http.MethodGet, "/generate?middle=0537", nil), initDefaultSegments())
+	if plain.status != http.StatusInternalServerError {
+		t.Fatalf("a ResponseWriter without Flush got status %d", plain.status)
+	}
+}
+
+// 只实现http.ResponseWriter，不实现http.Flusher
+type plainResponseWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *plainResponseWriter) Header() http.Header         { return w.header }
+func (w *plainResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *plainResponseWriter) WriteHeader(status int)      { w.status = status }
+
+// limit截取输出；超出服务端上限的范围不带limit时被拒绝
+func TestHandleGenerateLimit(t *testing.T) {
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	for _, tc := range []struct {
+		query  string
+		status int
+		lines  int
+	}{
+		{"?middle=*&limit=3", http.StatusOK, 3},
+		{"?middle=0537&operators=telecom&limit=100000", http.StatusOK, 90000},
+		{"?middle=*", http.StatusRequestEntityTooLarge, 0},
+		{"?middle=05*", http.StatusRequestEntityTooLarge, 0},
+		{"?middle=0537&limit=0", http.StatusBadRequest, 0},
+		{"?middle=0537&limit=-1", http.StatusBadRequest, 0},
+		{"?middle=0537&limit=abc", http.StatusBadRequest, 0},
+		{"?middle=0537&limit=10000001", http.StatusBadRequest, 0},
+	} {
+		rec := httptest.NewRecorder()
+		handleGenerate(rec, httptest.NewRequest(http.MethodGet, "/generate"+tc.query, nil), initDefaultSegments())
+		if rec.Code != tc.status {
+			t.Errorf("%s returned %d, want %d: %s", tc.query, rec.Code, tc.status, rec.Body.String())
+			continue
+		}
+		if tc.status == http.StatusOK {
+			if lines := strings.Count(rec.Body.String(), "\n"); lines != tc.lines {
+				t.Errorf("%s returned %d lines, want %d", tc.query, lines, tc.lines)
+			}
+		}
+	}
+}
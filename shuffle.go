@@ -0,0 +1,51 @@
+package main
+
+// 基于Feistel网络的伪随机置换，把[0,n)映射为[0,n)的一个排列，
+// 无需把全部号码读入内存即可按随机顺序输出
+type permutation struct {
+	n        uint64
+	halfBits uint
+	mask     uint64
+	keys     [4]uint64
+}
+
+func newPermutation(n uint64, seed int64) *permutation {
+	p := &permutation{n: n}
+	bits := uint(1)
+	for uint64(1)<<(2*bits) < n {
+		bits++
+	}
+	p.halfBits = bits
+	p.mask = 1<<bits - 1
+	state := uint64(seed)
+	for i := range p.keys {
+		state = splitmix64(state)
+		p.keys[i] = state
+	}
+	return p
+}
+
+// 返回第i个位置对应的原始下标。Feistel在2^(2*halfBits)上是双射，
+// 结果超出[0,n)时继续迭代（cycle walking），保证仍是[0,n)上的双射
+func (p *permutation) at(i uint64) uint64 {
+	x := p.encrypt(i)
+	for x >= p.n {
+		x = p.encrypt(x)
+	}
+	return x
+}
+
+func (p *permutation) encrypt(x uint64) uint64 {
+	left, right := x>>p.halfBits, x&p.mask
+	for _, key := range p.keys {
+		left, right = right, left^(splitmix64(right^key)&p.mask)
+	}
+	return left<<p.halfBits | right
+}
+
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
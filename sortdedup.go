@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 外部归并排序去重：分块读入内存排序后写入临时块文件，再多路归并并去掉重复行，
+// 适用于无法一次装入内存的大文件
+func sortDedupFile(path string, chunkLines int) (err error) {
+	if chunkLines <= 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", chunkLines)
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer in.Close()
+
+	var chunkPaths []string
+	defer func() {
+		for _, p := range chunkPaths {
+			os.Remove(p)
+		}
+	}()
+
+	// 空行不计入原始行数，单独报告，避免被算作重复行
+	originalCount, blankCount := 0, 0
+	chunk := make([]string, 0, chunkLines)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			blankCount++
+			continue
+		}
+		originalCount++
+		chunk = append(chunk, line)
+		if len(chunk) == chunkLines {
+			p, err := writeSortedChunk(filepath.Dir(path), chunk)
+			if err != nil {
+				return err
+			}
+			chunkPaths = append(chunkPaths, p)
+			chunk = chunk[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if len(chunk) > 0 {
+		p, err := writeSortedChunk(filepath.Dir(path), chunk)
+		if err != nil {
+			return err
+		}
+		chunkPaths = append(chunkPaths, p)
+	}
+	in.Close()
+
+	fmt.Fprintf(infoOut, "Sorted %d lines into %d chunk(s), merging...\n", originalCount, len(chunkPaths))
+	finalCount, err := mergeChunks(chunkPaths, path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(infoOut, "✅ Sort and dedup completed: %s | Original lines: %d | Final lines: %d | Duplicates removed: %d | Blank lines removed: %d\n",
+		path, originalCount, finalCount, originalCount-finalCount, blankCount)
+	return nil
+}
+
+// 对单个块排序去重后写入临时文件，返回文件路径
+func writeSortedChunk(dir string, lines []string) (string, error) {
+	sort.Strings(lines)
+	file, err := os.CreateTemp(dir, "sortdedup-*.chunk")
+	if err != nil {
+		return "", fmt.Errorf("failed to create chunk file: %v", err)
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	for i, line := range lines {
+		if i > 0 && line == lines[i-1] {
+			continue
+		}
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			return file.Name(), fmt.Errorf("failed to write chunk file: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return file.Name(), fmt.Errorf("failed to write chunk file: %v", err)
+	}
+	return file.Name(), nil
+}
+
+type chunkCursor struct {
+	line    string
+	scanner *bufio.Scanner
+}
+
+// 按当前行排序的小顶堆
+type chunkHeap []*chunkCursor
+
+func (h chunkHeap) Len() int           { return len(h) }
+func (h chunkHeap) Less(i, j int) bool { return h[i].line < h[j].line }
+func (h chunkHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x any)        { *h = append(*h, x.(*chunkCursor)) }
+func (h *chunkHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// 多路归并所有块文件到outputPath（先写临时文件再重命名），返回去重后的行数
+func mergeChunks(chunkPaths []string, outputPath string) (count int, err error) {
+	h := &chunkHeap{}
+	for _, p := range chunkPaths {
+		file, err := os.Open(p)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open chunk file: %v", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		if scanner.Scan() {
+			heap.Push(h, &chunkCursor{line: scanner.Text(), scanner: scanner})
+		} else if err := scanner.Err(); err != nil {
+			return 0, fmt.Errorf("failed to read chunk file: %v", err)
+		}
+	}
+
+	tmpPath := outputPath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %v", tmpPath, err)
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+	writer := bufio.NewWriter(out)
+
+	last := ""
+	for h.Len() > 0 {
+		c := (*h)[0]
+		if count == 0 || c.line != last {
+			if _, err := writer.WriteString(c.line + "\n"); err != nil {
+				return 0, fmt.Errorf("failed to write %s: %v", tmpPath, err)
+			}
+			last = c.line
+			count++
+		}
+		if c.scanner.Scan() {
+			c.line = c.scanner.Text()
+			heap.Fix(h, 0)
+		} else {
+			if err := c.scanner.Err(); err != nil {
+				return 0, fmt.Errorf("failed to read chunk file: %v", err)
+			}
+			heap.Pop(h)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", tmpPath, err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close %s: %v", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return 0, fmt.Errorf("failed to rename %s to %s: %v", tmpPath, outputPath, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 空行被去掉但不算作重复行；块很小时也要跨块去重
+func TestSortDedupFileBlankLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "numbers.txt")
+	input := "13705370002\n\n13705370001\n13705370002\n   \n13705370000\n"
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	stdout := captureStdout(t, func() {
+		err = sortDedupFile(path, 2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "13705370000\n13705370001\n13705370002\n"; string(data) != want {
+		t.Fatalf("got %q, want %q", data, want)
+	}
+	want := "Original lines: 4 | Final lines: 3 | Duplicates removed: 1 | Blank lines removed: 2"
+	if !strings.Contains(stdout, want) {
+		t.Fatalf("summary is missing %q:\n%s", want, stdout)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "*.chunk")); len(matches) > 0 {
+		t.Fatalf("chunk files were left behind: %v", matches)
+	}
+}
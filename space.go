@@ -0,0 +1,231 @@
+package main
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// 尾号序列，按下标给出第i个尾号
+type suffixSeq interface {
+	Len() int
+	At(i int) int
+}
+
+// 从start开始、间隔为step的count个尾号
+type suffixRange struct {
+	start, step, count int
+}
+
+func (r suffixRange) Len() int     { return r.count }
+func (r suffixRange) At(i int) int { return r.start + i*r.step }
+
+// 倒序的尾号序列
+type reversedSuffixes struct {
+	suffixSeq
+}
+
+func (r reversedSuffixes) At(i int) int { return r.suffixSeq.At(r.Len() - 1 - i) }
+
+// 只取前n个尾号
+type firstSuffixes struct {
+	suffixSeq
+	n int
+}
+
+func (f firstSuffixes) Len() int { return min(f.n, f.suffixSeq.Len()) }
+
+// 一个号段+中间码组合及其尾号序列
+type pairBlock struct {
+	prefix   string
+	middle   string
+	suffixes suffixSeq
+	offset   uint64 // 在所属组合空间中的起始下标
+}
+
+// 组合空间：按顺序排列的组合块，可按全局下标定位到具体号码
+type space struct {
+	blocks []pairBlock
+	total  uint64
+}
+
+func newSpace(blocks []pairBlock) *space {
+	s := &space{blocks: blocks}
+	for i := range s.blocks {
+		s.blocks[i].offset = s.total
+		s.total += uint64(s.blocks[i].suffixes.Len())
+	}
+	return s
+}
+
+// 返回全局下标idx对应的号码组成部分
+func (s *space) at(idx uint64) (string, string, int) {
+	i := sort.Search(len(s.blocks), func(i int) bool {
+		return s.blocks[i].offset > idx
+	}) - 1
+	b := s.blocks[i]
+	return b.prefix, b.middle, b.suffixes.At(int(idx - b.offset))
+}
+
+// 按号段拆分为子空间，保持号段的原始顺序
+func (s *space) byPrefix() ([]string, []*space) {
+	var prefixes []string
+	grouped := make(map[string][]pairBlock)
+	for _, b := range s.blocks {
+		if _, ok := grouped[b.prefix]; !ok {
+			prefixes = append(prefixes, b.prefix)
+		}
+		grouped[b.prefix] = append(grouped[b.prefix], b)
+	}
+	spaces := make([]*space, len(prefixes))
+	for i, prefix := range prefixes {
+		spaces[i] = newSpace(grouped[prefix])
+	}
+	return prefixes, spaces
+}
+
+// 根据生成参数构造组合空间
+func (c genConfig) buildSpace(prefixes, middleCodes []string) *space {
+	var blocks []pairBlock
+	full := c.suffixesIn([]suffixBlockRange{{0, pow10(c.suffixLen) - 1}})
+	if c.sorted {
+		prefixes = sortedCopy(prefixes)
+	}
+	for _, prefix := range prefixes {
+		pc, prefixFull := c, full
+		if r, ok := c.prefixRanges[prefix]; ok {
+			pc.suffixStart, pc.suffixEnd = r.start, r.end
+			prefixFull = pc.suffixesIn([]suffixBlockRange{{0, pow10(c.suffixLen) - 1}})
+		}
+		for _, middle := range c.middlesFor(prefix, middleCodes) {
+			suffixes := prefixFull
+			if c.blocks != nil {
+				ranges := c.blocks.ranges(prefix, middle)
+				if len(ranges) == 0 {
+					continue
+				}
+				suffixes = pc.suffixesIn(ranges)
+			}
+			if c.shuffleSuffix {
+				suffixes = c.shuffledSuffixes(prefix, middle, suffixes)
+			}
+			blocks = append(blocks, pairBlock{prefix: prefix, middle: middle, suffixes: suffixes})
+		}
+	}
+	return newSpace(blocks)
+}
+
+// 按随机置换后的下标取尾号
+type permutedSuffixes struct {
+	suffixSeq
+	perm *permutation
+}
+
+func (s permutedSuffixes) At(i int) int {
+	return s.suffixSeq.At(int(s.perm.at(uint64(i))))
+}
+
+// 组合内的尾号置换，种子由suffixSeed和号段、中间码共同决定，各组合的顺序互不相同
+func (c genConfig) shuffledSuffixes(prefix, middle string, suffixes suffixSeq) suffixSeq {
+	h := fnv.New64a()
+	h.Write([]byte(prefix + "/" + middle))
+	seed := int64(splitmix64(uint64(c.suffixSeed) ^ h.Sum64()))
+	return permutedSuffixes{suffixes, newPermutation(uint64(suffixes.Len()), seed)}
+}
+
+// 多个尾号序列首尾相接
+type concatSuffixes []suffixRange
+
+func (s concatSuffixes) Len() int {
+	n := 0
+	for _, r := range s {
+		n += r.count
+	}
+	return n
+}
+
+func (s concatSuffixes) At(i int) int {
+	for _, r := range s {
+		if i < r.count {
+			return r.At(i)
+		}
+		i -= r.count
+	}
+	panic("suffix index out of range")
+}
+
+// 在互不重叠、从小到大排列的区间内，按尾号范围、间隔、分片、倒序和-per-pair生成尾号序列
+func (c genConfig) suffixesIn(ranges []suffixBlockRange) suffixSeq {
+	if c.suffixDigits != "" {
+		return c.orderedSuffixes(newDigitSuffixes(c.suffixDigits, c.suffixLen))
+	}
+	start, end, step := c.suffixBounds()
+	var parts concatSuffixes
+	for _, r := range ranges {
+		// 取等差序列落在[r.start, r.end]内的部分
+		first := start
+		if r.start > start {
+			first = start + (r.start-start+step-1)/step*step
+		}
+		s, e, st := first, min(end, r.end), step
+		if c.shardCount > 0 {
+			s, e, st = shardSuffixes(s, e, st, c.shard, c.shardCount)
+		}
+		if count := int(EstimateCount([]string{""}, []string{""}, s, e, st)); count > 0 {
+			parts = append(parts, suffixRange{start: s, step: st, count: count})
+		}
+	}
+	var suffixes suffixSeq = parts
+	if len(parts) == 1 {
+		suffixes = parts[0]
+	}
+	return c.orderedSuffixes(suffixes)
+}
+
+// 按-reverse-suffix和-per-pair调整尾号序列
+func (c genConfig) orderedSuffixes(suffixes suffixSeq) suffixSeq {
+	if c.reverseSuffix {
+		suffixes = reversedSuffixes{suffixes}
+	}
+	if c.perPair > 0 {
+		suffixes = firstSuffixes{suffixes, c.perPair}
+	}
+	return suffixes
+}
+
+// 从start到end、间隔step的尾号中选出suffix%m==r的部分，结果仍是等差序列；
+// 没有满足条件的尾号时返回end<start的空范围
+func shardSuffixes(start, end, step, r, m int) (int, int, int) {
+	period := m / gcd(step, m)
+	for i := 0; i < period; i++ {
+		if suffix := start + i*step; suffix > end {
+			break
+		} else if suffix%m == r {
+			return suffix, end, step * period
+		}
+	}
+	return start, start - 1, step
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// 号段可用的中间码：有限制表时取限制表，否则使用全部中间码
+func (c genConfig) middlesFor(prefix string, middleCodes []string) []string {
+	if allowed, ok := c.prefixMiddles[prefix]; ok {
+		middleCodes = allowed
+	}
+	if c.sorted {
+		return sortedCopy(middleCodes)
+	}
+	return middleCodes
+}
+
+func sortedCopy(s []string) []string {
+	sorted := append([]string(nil), s...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// -stats：生成过程中统计尾号首位的分布和最小、最大号码，结束后与各号段的数量一起输出，
+// 用于确认过滤条件得到的号码分布符合预期。-out-parallel的各部分并发调用add
+type numberStats struct {
+	mu         sync.Mutex
+	count      int64
+	firstDigit [10]int64
+	min, max   numberParts
+}
+
+// 号码的组成部分；号段和中间码各自等长，按号段、中间码、尾号依次比较即为号码的大小顺序
+type numberParts struct {
+	prefix, middle string
+	suffix         int
+}
+
+func (a numberParts) less(b numberParts) bool {
+	if a.prefix != b.prefix {
+		return a.prefix < b.prefix
+	}
+	if a.middle != b.middle {
+		return a.middle < b.middle
+	}
+	return a.suffix < b.suffix
+}
+
+func (p numberParts) String() string {
+	return fmt.Sprintf("%s%s%0*d", p.prefix, p.middle, layout.suffixLen, p.suffix)
+}
+
+func (s *numberStats) add(prefix, middle string, suffix int) {
+	n := numberParts{prefix, middle, suffix}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.firstDigit[suffix/pow10(layout.suffixLen-1)]++
+	if s.count == 0 || n.less(s.min) {
+		s.min = n
+	}
+	if s.count == 0 || s.max.less(n) {
+		s.max = n
+	}
+	s.count++
+}
+
+// -stats-json写入的内容
+type statsReport struct {
+	Numbers          int64            `json:"numbers"`
+	Min              string           `json:"min,omitempty"`
+	Max              string           `json:"max,omitempty"`
+	Operators        []statsOperator  `json:"operators"`
+	Prefixes         []statsPrefix    `json:"prefixes"`
+	FirstSuffixDigit map[string]int64 `json:"firstSuffixDigit"`
+}
+
+type statsOperator struct {
+	Operator string `json:"operator"`
+	Count    int64  `json:"count"`
+}
+
+type statsPrefix struct {
+	Prefix   string `json:"prefix"`
+	Operator string `json:"operator"`
+	Count    int64  `json:"count"`
+}
+
+// 合并各号段的数量（生成时已按号段统计）和尾号分布
+func (s *numberStats) report(prefixes []string, operators map[string]string, prefixCounts map[string]int64) statsReport {
+	r := statsReport{Numbers: s.count, FirstSuffixDigit: make(map[string]int64)}
+	if s.count > 0 {
+		r.Min, r.Max = s.min.String(), s.max.String()
+	}
+	byOperator := make(map[string]int64)
+	for _, prefix := range prefixes {
+		r.Prefixes = append(r.Prefixes, statsPrefix{prefix, operators[prefix], prefixCounts[prefix]})
+		byOperator[operators[prefix]] += prefixCounts[prefix]
+	}
+	for _, operator := range operatorOrder {
+		if n, ok := byOperator[operator]; ok {
+			r.Operators = append(r.Operators, statsOperator{operator, n})
+		}
+	}
+	for d, n := range s.firstDigit {
+		r.FirstSuffixDigit[fmt.Sprint(d)] = n
+	}
+	return r
+}
+
+// 占总数的百分比，总数为0时为0
+func percentOf(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+func printStats(r statsReport) {
+	logf("\n📊 Statistics (-stats):\n")
+	if r.Numbers == 0 {
+		logf("Numbers: 0\n")
+		return
+	}
+	logf("Numbers: %d | Min: %s | Max: %s\n", r.Numbers, r.Min, r.Max)
+	logf("By operator:\n")
+	for _, o := range r.Operators {
+		logf("  %-14s %12d  %5.1f%%\n", operatorLabel(o.Operator), o.Count, percentOf(o.Count, r.Numbers))
+	}
+	logf("By prefix:\n")
+	for _, p := range r.Prefixes {
+		if p.Count > 0 {
+			logf("  %s %-14s %12d  %5.1f%%\n", p.Prefix, operatorLabel(p.Operator), p.Count, percentOf(p.Count, r.Numbers))
+		}
+	}
+	logf("By first suffix digit:\n")
+	for d := 0; d < 10; d++ {
+		n := r.FirstSuffixDigit[fmt.Sprint(d)]
+		logf("  %d %12d  %5.1f%%\n", d, n, percentOf(n, r.Numbers))
+	}
+}
+
+// 以缩进JSON写入统计结果
+func writeStatsJSON(path string, r statsReport) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode statistics: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// -suffix-digits直接给出每位尾号可用的数字，不能再与按数值截取尾号的参数同时使用
+var suffixDigitsIncompatibleFlags = []string{"suffix-start", "suffix-end", "suffix-step", "shard", "block-file"}
+
+// 解析-suffix-digits，如08或02468：只能包含0-9，去重后按从小到大排列
+func parseSuffixDigits(input string) (string, error) {
+	if input == "" {
+		return "", fmt.Errorf("invalid -suffix-digits: no digits given")
+	}
+	seen := make(map[rune]bool)
+	var digits []rune
+	for _, ch := range input {
+		if ch < '0' || ch > '9' {
+			return "", fmt.Errorf("invalid -suffix-digits %q (only the digits 0-9 are allowed)", input)
+		}
+		if !seen[ch] {
+			seen[ch] = true
+			digits = append(digits, ch)
+		}
+	}
+	sort.Slice(digits, func(i, j int) bool { return digits[i] < digits[j] })
+	return string(digits), nil
+}
+
+func checkSuffixDigitsOptions(opts options) error {
+	if !opts.set["suffix-digits"] {
+		return nil
+	}
+	if _, err := parseSuffixDigits(opts.suffixDigits); err != nil {
+		return err
+	}
+	for _, name := range suffixDigitsIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-suffix-digits cannot be used with -%s", name)
+		}
+	}
+	return nil
+}
+
+// 每位只取digits中数字的尾号，共len(digits)^length个，digits从小到大排列时尾号也从小到大
+type digitSuffixes struct {
+	digits []int
+	length int
+	count  int
+}
+
+func newDigitSuffixes(digits string, length int) digitSuffixes {
+	s := digitSuffixes{length: length, count: 1}
+	for _, ch := range digits {
+		s.digits = append(s.digits, int(ch-'0'))
+	}
+	for i := 0; i < length; i++ {
+		s.count *= len(s.digits)
+	}
+	return s
+}
+
+func (s digitSuffixes) Len() int { return s.count }
+
+// 把下标i看作len(digits)进制数，每一位换成对应的数字
+func (s digitSuffixes) At(i int) int {
+	base := len(s.digits)
+	suffix, scale := 0, 1
+	for n := 0; n < s.length; n++ {
+		suffix += s.digits[i%base] * scale
+		i /= base
+		scale *= 10
+	}
+	return suffix
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseSuffixDigits(t *testing.T) {
+	for _, tc := range []struct {
+		input, want string
+		ok          bool
+	}{
+		{"08", "08", true},
+		{"80", "08", true},
+		{"2468022", "02468", true},
+		{"", "", false},
+		{"0a", "", false},
+		{"1,8", "", false},
+	} {
+		got, err := parseSuffixDigits(tc.input)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parseSuffixDigits(%q) = %q, %v", tc.input, got, err)
+		}
+	}
+}
+
+// 只用0和8时每个组合有2^4个尾号，按从小到大输出
+func TestGenerateSuffixDigits(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137"}, []string{"0537"}, WithSuffixDigits("08"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"0000", "0008", "0080", "0088", "0800", "0808", "0880", "0888",
+		"8000", "8008", "8080", "8088", "8800", "8808", "8880", "8888",
+	}
+	for i := range want {
+		want[i] = "1370537" + want[i]
+	}
+	if n != 16 || buf.String() != strings.Join(want, "\n")+"\n" {
+		t.Fatalf("got %d numbers:\n%s", n, buf.String())
+	}
+	if got := newGenConfig([]Option{WithSuffixDigits("08")}).total([]string{"137", "138"}, []string{"0537"}); got != 32 {
+		t.Fatalf("total = %d, want 32", got)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// OperatorSuffixRange 配置中某个运营商的号段使用的尾号范围（含两端）
+type OperatorSuffixRange struct {
+	Start int `json:"start" yaml:"start" toml:"start"`
+	End   int `json:"end" yaml:"end" toml:"end"`
+}
+
+// 校验operatorSuffixRanges：运营商必须是mobile、unicom或telecom，范围在0到10^n-1之间且start<=end
+func checkOperatorSuffixRanges(ranges map[string]OperatorSuffixRange, configPath string) error {
+	maxSuffix := pow10(layout.suffixLen) - 1
+	operators := make([]string, 0, len(ranges))
+	for operator := range ranges {
+		operators = append(operators, operator)
+	}
+	sort.Strings(operators)
+	for _, operator := range operators {
+		r := ranges[operator]
+		if !slices.Contains(operatorOrder, operator) {
+			return newConfigError(ErrConfigParse, configPath, nil,
+				"invalid operatorSuffixRanges in %s: unknown operator %q (use mobile, unicom or telecom)", configPath, operator)
+		}
+		if r.Start < 0 || r.End < r.Start || r.End > maxSuffix {
+			return newConfigError(ErrConfigParse, configPath, nil,
+				"invalid operatorSuffixRanges.%s in %s: start %d and end %d must satisfy 0 <= start <= end <= %d", operator, configPath, r.Start, r.End, maxSuffix)
+		}
+	}
+	return nil
+}
+
+// 把operatorSuffixRanges展开为号段到尾号范围的映射，没有配置的运营商使用全局范围，不出现在结果中；未配置时返回nil
+func (config Config) prefixSuffixRanges(segments Segments) map[string]suffixBlockRange {
+	if len(config.OperatorSuffixRanges) == 0 {
+		return nil
+	}
+	ranges := make(map[string]suffixBlockRange)
+	for operator, r := range config.OperatorSuffixRanges {
+		for _, prefix := range segments.Prefixes(operator) {
+			ranges[prefix] = suffixBlockRange{start: r.Start, end: r.End}
+		}
+	}
+	return ranges
+}
+
+// 按运营商顺序报告各自的尾号范围，只列出有号段参与生成的运营商
+func reportOperatorSuffixRanges(ranges map[string]OperatorSuffixRange, segments Segments) {
+	for _, operator := range operatorOrder {
+		r, ok := ranges[operator]
+		if !ok || len(segments.Prefixes(operator)) == 0 {
+			continue
+		}
+		logf("Suffix range for %s prefixes (operatorSuffixRanges): %0*d-%0*d (%d suffixes)\n",
+			operatorLabel(operator), layout.suffixLen, r.Start, layout.suffixLen, r.End, r.End-r.Start+1)
+	}
+}
+
+// operatorSuffixRanges按运营商替换尾号范围，与按数值取尾号以外的方式冲突
+func checkSuffixRangesOptions(config Config, formats []string, opts options) error {
+	if len(config.OperatorSuffixRanges) == 0 {
+		return nil
+	}
+	if opts.suffixDigits != "" {
+		return fmt.Errorf("-suffix-digits cannot be used with operatorSuffixRanges in %s", configFile)
+	}
+	if opts.suffixLens != "" {
+		return fmt.Errorf("-suffix-len-range cannot be used with operatorSuffixRanges in %s", configFile)
+	}
+	for _, format := range formats {
+		if format == "mask" {
+			return fmt.Errorf("-format=mask cannot be used with operatorSuffixRanges in %s (a mask always covers every suffix)", configFile)
+		}
+	}
+	return nil
+}
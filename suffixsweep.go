@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// -suffix-len-range依次生成多种位数的尾号，用于测试对不同长度号码的处理；
+// 每种位数都生成完整的尾号空间，不能与截取尾号、改变顺序或要求固定长度的参数同时使用
+var suffixLenRangeIncompatibleFlags = []string{
+	"suffix-len", "suffix-start", "suffix-end", "suffix-digits", "shard", "per-pair", "block-file",
+	"sample", "weights", "shuffle", "seed", "daily-seed", "preview", "e164", "complement",
+	"stats", "stats-json", "workers", "auto-tune", "out-parallel",
+}
+
+// 解析-suffix-len-range，如3-5，两端都是1到9之间的位数且前者不大于后者
+func parseSuffixLenRange(input string) (int, int, error) {
+	lo, hi, ok := strings.Cut(input, "-")
+	minLen, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	maxLen, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if !ok || err1 != nil || err2 != nil || minLen < 1 || maxLen > 9 || minLen > maxLen {
+		return 0, 0, fmt.Errorf("invalid -suffix-len-range %q (expected min-max with 1 <= min <= max <= 9, e.g. 3-5)", input)
+	}
+	return minLen, maxLen, nil
+}
+
+func checkSuffixLenRangeOptions(opts options) error {
+	if !opts.set["suffix-len-range"] {
+		return nil
+	}
+	if _, _, err := parseSuffixLenRange(opts.suffixLens); err != nil {
+		return err
+	}
+	for _, name := range suffixLenRangeIncompatibleFlags {
+		if opts.set[name] {
+			return fmt.Errorf("-suffix-len-range cannot be used with -%s", name)
+		}
+	}
+	if formats, err := parseFormats(opts.format); err == nil {
+		for _, format := range formats {
+			if format == "binary" || format == "mask" || format == "ranges" {
+				return fmt.Errorf("-suffix-len-range cannot be used with -format=%s", format)
+			}
+		}
+	}
+	return nil
+}
+
+// 各位数的尾号数量之和，即每个号段+中间码组合生成的号码数
+func sweepSuffixCount(opts options) int64 {
+	minLen, maxLen, _ := parseSuffixLenRange(opts.suffixLens)
+	var count int64
+	for n := minLen; n <= maxLen; n++ {
+		count += EstimateCount([]string{""}, []string{""}, 0, pow10(n)-1, opts.suffixStep)
+	}
+	return count
+}
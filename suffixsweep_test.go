@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseSuffixLenRange(t *testing.T) {
+	if lo, hi, err := parseSuffixLenRange(" 3 - 5 "); err != nil || lo != 3 || hi != 5 {
+		t.Fatalf("parseSuffixLenRange(3-5) = %d, %d, %v", lo, hi, err)
+	}
+	for _, input := range []string{"", "3", "5-3", "0-2", "3-10", "a-5", "3-5-7"} {
+		if _, _, err := parseSuffixLenRange(input); err == nil {
+			t.Errorf("parseSuffixLenRange(%q) was accepted", input)
+		}
+	}
+}
+
+// 3到5位尾号依次生成，得到10、11和12位的号码，每种长度都是完整且补零的尾号空间
+func TestGenerateSuffixLenRange(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := Generate(&buf, []string{"137"}, []string{"0537"}, WithSuffixLenRange(3, 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 111000 {
+		t.Fatalf("Generate reported %d numbers, want 111000", n)
+	}
+	lengths := map[int]int{}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		lengths[len(line)]++
+	}
+	for length, want := range map[int]int{10: 1000, 11: 10000, 12: 100000} {
+		if lengths[length] != want {
+			t.Errorf("got %d numbers of %d digits, want %d", lengths[length], length, want)
+		}
+	}
+	if len(lengths) != 3 {
+		t.Errorf("got lengths %v, want only 10, 11 and 12", lengths)
+	}
+	for _, number := range []string{"1370537000\n", "1370537999\n", "13705370000\n", "137053799999\n"} {
+		if !strings.Contains(buf.String(), number) {
+			t.Errorf("%q is missing", strings.TrimSpace(number))
+		}
+	}
+	opts := legacyOptions(t, "-suffix-len-range", "3-5")
+	if got := sweepSuffixCount(opts); got != 111000 {
+		t.Errorf("sweepSuffixCount = %d, want 111000", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 默认模板，与直接拼接号段、中间码和尾号相同
+const defaultTemplate = "{prefix}{middle}{suffix}"
+
+// 模板中的一个片段：字面量或字段
+type templateToken struct {
+	literal string
+	field   string // prefix、middle、suffix之一，为空表示字面量
+	width   int    // 尾号补零宽度，0表示使用尾号位数
+}
+
+// Template 号码模板，由ParseTemplate解析得到
+type Template struct {
+	tokens []templateToken
+}
+
+// ParseTemplate 解析号码模板，如{prefix}-{middle}-{suffix:4}。
+// 支持的字段为{prefix}、{middle}、{suffix}，{suffix:N}表示尾号补零到N位，其余字符原样输出
+func ParseTemplate(s string) (Template, error) {
+	var t Template
+	for rest := s; rest != ""; {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.tokens = append(t.tokens, templateToken{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return Template{}, fmt.Errorf("invalid template %q: unexpected '}'", s)
+		}
+		if open > 0 {
+			t.tokens = append(t.tokens, templateToken{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return Template{}, fmt.Errorf("invalid template %q: missing '}'", s)
+		}
+		token, err := parseTemplateField(rest[open+1 : open+end])
+		if err != nil {
+			return Template{}, fmt.Errorf("invalid template %q: %v", s, err)
+		}
+		t.tokens = append(t.tokens, token)
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+func parseTemplateField(field string) (templateToken, error) {
+	name, width, hasWidth := strings.Cut(field, ":")
+	switch name {
+	case "prefix", "middle":
+		if hasWidth {
+			return templateToken{}, fmt.Errorf("{%s} does not take a width", name)
+		}
+		return templateToken{field: name}, nil
+	case "suffix":
+		if !hasWidth {
+			return templateToken{field: name}, nil
+		}
+		n, err := strconv.Atoi(width)
+		if err != nil || n < 1 || n > 9 {
+			return templateToken{}, fmt.Errorf("invalid suffix width %q (must be between 1 and 9)", width)
+		}
+		return templateToken{field: name, width: n}, nil
+	}
+	return templateToken{}, fmt.Errorf("unknown field {%s} (must be prefix, middle or suffix)", field)
+}
+
+// -separator-fuzz轮流使用的分隔符样式，用于测试号码解析器的健壮性
+var separatorFuzzStyles = []string{
+	"{prefix}{middle}{suffix}",
+	"{prefix}-{middle}-{suffix}",
+	"{prefix} {middle} {suffix}",
+	"({prefix}){middle}{suffix}",
+	"{prefix}.{middle}.{suffix}",
+	"({prefix}) {middle}-{suffix}",
+}
+
+// 模板是否等同于默认的直接拼接
+func (t Template) isDefault(suffixLen int) bool {
+	if len(t.tokens) != 3 {
+		return false
+	}
+	for i, field := range []string{"prefix", "middle", "suffix"} {
+		if t.tokens[i].field != field {
+			return false
+		}
+	}
+	return t.tokens[2].width == 0 || t.tokens[2].width == suffixLen
+}
+
+func (t Template) format(prefix, middle string, suffix, suffixLen int) string {
+	var b strings.Builder
+	for _, token := range t.tokens {
+		switch token.field {
+		case "prefix":
+			b.WriteString(prefix)
+		case "middle":
+			b.WriteString(middle)
+		case "suffix":
+			width := token.width
+			if width == 0 {
+				width = suffixLen
+			}
+			digits := strconv.Itoa(suffix)
+			for i := len(digits); i < width; i++ {
+				b.WriteByte('0')
+			}
+			b.WriteString(digits)
+		default:
+			b.WriteString(token.literal)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSeparatorFuzzUsesAllStyles(t *testing.T) {
+	opts := legacyOptions(t, "-separator-fuzz")
+	var buf bytes.Buffer
+	n := len(separatorFuzzStyles) * 3
+	if _, err := Generate(&buf, []string{"137"}, []string{"0537"}, append(formatOptions(opts), WithLimit(int64(n)))...); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != n {
+		t.Fatalf("got %d lines, want %d", len(lines), n)
+	}
+	for i, style := range separatorFuzzStyles {
+		template, err := ParseTemplate(style)
+		if err != nil {
+			t.Fatalf("style %s: %v", style, err)
+		}
+		if want := template.format("137", "0537", i, 4); lines[i] != want {
+			t.Errorf("line %d is %q, want %q", i, lines[i], want)
+		}
+	}
+	for _, want := range []string{"137-0537-0001", "137 0537 0002", "(137)05370003"} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("output has no %s", want)
+		}
+	}
+}
+
+func TestTemplateFormat(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		want     string
+	}{
+		{"{prefix}{middle}{suffix}", "13705370042"},
+		{"{prefix}-{middle}-{suffix:6}", "137-0537-000042"},
+		{"+86 {prefix} {middle} {suffix}", "+86 137 0537 0042"},
+	} {
+		template, err := ParseTemplate(tc.template)
+		if err != nil {
+			t.Fatalf("ParseTemplate(%q) returned %v", tc.template, err)
+		}
+		if got := template.format("137", "0537", 42, 4); got != tc.want {
+			t.Errorf("%s formatted as %q, want %q", tc.template, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// 查询不到终端宽度时使用的默认宽度
+const defaultTerminalWidth = 80
+
+// f是否为终端，重定向到文件或管道时返回false
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// 查询终端列数，失败时返回0
+func terminalWidth(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// 在终端中原地刷新的进度行，窗口缩放（SIGWINCH）后重新查询宽度，自动适应新的宽度
+type progressLine struct {
+	shown   bool
+	width   int
+	resized <-chan os.Signal
+	stop    func()
+}
+
+// 查询当前宽度并开始监听窗口大小变化，结束时需调用finish
+func newProgressLine() *progressLine {
+	p := &progressLine{width: terminalWidth(infoFile())}
+	p.resized, p.stop = notifyResize()
+	return p
+}
+
+// 按终端宽度输出进度：计数、百分比和填满剩余宽度的进度条，太窄时截断
+func (p *progressLine) update(done, total int64) {
+	select {
+	case <-p.resized:
+		p.width = terminalWidth(infoFile())
+	default:
+	}
+	width := p.width
+	if width <= 0 {
+		width = defaultTerminalWidth
+	}
+	width-- // 留出光标位置，避免在最后一列自动换行
+	line := fmt.Sprintf("Generated: %d / %d", done, total)
+	if total > 0 {
+		percent := float64(done) / float64(total)
+		line += fmt.Sprintf(" %5.1f%%", percent*100)
+		if barWidth := width - len(line) - 3; barWidth >= 10 {
+			filled := int(percent * float64(barWidth))
+			line += " [" + strings.Repeat("#", filled) + strings.Repeat(".", barWidth-filled) + "]"
+		}
+	}
+	if len(line) > width {
+		line = line[:width]
+	}
+	fmt.Fprintf(infoOut, "\r%s\033[K", line)
+	p.shown = true
+}
+
+// 结束进度行并停止监听窗口大小变化，之后的输出从新的一行开始
+func (p *progressLine) finish() {
+	p.stop()
+	if p.shown {
+		fmt.Fprintln(infoOut)
+		p.shown = false
+	}
+}
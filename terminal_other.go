@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd
+
+package main
+
+import "os"
+
+// 其他平台没有SIGWINCH，宽度只在开始时查询一次
+func notifyResize() (resized <-chan os.Signal, stop func()) {
+	return nil, func() {}
+}
@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// 终端窗口大小变化（SIGWINCH）时向返回的通道发送通知，调用stop停止监听
+func notifyResize() (resized <-chan os.Signal, stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGWINCH)
+	return ch, func() { signal.Stop(ch) }
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// -out为http://或https://地址时不写本地文件，而是用分块传输的PUT请求边生成边上传；
+// 为s3://bucket/key时用S3分段上传
+func isRemoteOutput(out string) bool {
+	return strings.HasPrefix(out, "http://") || strings.HasPrefix(out, "https://") || strings.HasPrefix(out, "s3://")
+}
+
+// 在开始生成前检查s3://地址是否包含存储桶和对象键
+func checkRemoteOutput(out string) error {
+	if strings.HasPrefix(out, "s3://") {
+		_, _, err := parseS3URL(out)
+		return err
+	}
+	return nil
+}
+
+// 远程上传：写入的数据经管道作为请求体发送，请求在后台进行
+type upload struct {
+	url  string
+	pw   *io.PipeWriter
+	done chan struct{} // 请求结束后关闭
+	err  error         // done关闭前写入
+}
+
+func startUpload(url string) (*upload, error) {
+	if strings.HasPrefix(url, "s3://") {
+		return startS3Upload(url)
+	}
+	pr, pw := io.Pipe()
+	req, err := http.NewRequest(http.MethodPut, url, pr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload URL %s: %v", url, err)
+	}
+	// 长度未知，按分块传输发送
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	u := &upload{url: url, pw: pw, done: make(chan struct{})}
+	u.start(pr, func() error {
+		return u.send(req)
+	})
+	return u, nil
+}
+
+// 在后台执行send，send从pr读取写入的数据；结束后pr随之关闭，之后的写入返回send的错误
+func (u *upload) start(pr *io.PipeReader, send func() error) {
+	go func() {
+		defer close(u.done)
+		u.err = send()
+		pr.CloseWithError(u.err)
+	}()
+}
+
+func (u *upload) send(req *http.Request) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload to %s: %v", u.url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err = fmt.Errorf("upload to %s rejected: %s", u.url, resp.Status)
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// 请求提前结束（连接失败或服务器拒绝）后，写入返回上传的错误
+func (u *upload) Write(p []byte) (int, error) {
+	n, err := u.pw.Write(p)
+	if err != nil {
+		<-u.done
+		if u.err != nil {
+			err = u.err
+		}
+	}
+	return n, err
+}
+
+// 结束请求体并等待服务器响应
+func (u *upload) finish() error {
+	u.pw.Close()
+	<-u.done
+	return u.err
+}
+
+// 生成失败时中断请求，服务器不会收到完整的请求体；可在finish之后调用
+func (u *upload) cancel() {
+	u.pw.CloseWithError(errors.New("generation aborted"))
+	<-u.done
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 反向校验：逐行检查号码的前缀是否为已知运营商号段、中间码是否在配置中
+func validateNumbersFile(path string, segments Segments, config Config, matchedOut string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer in.Close()
+
+	prefixOperators := segments.Operators()
+	middleSet := make(map[string]bool)
+	for _, code := range config.MiddleCodes {
+		middleSet[code] = true
+	}
+	// prefixMiddleMap中列出的号段只接受对应的中间码
+	allowed := make(map[string]map[string]bool)
+	for prefix, codes := range config.prefixMiddles(segments) {
+		allowed[prefix] = make(map[string]bool)
+		for _, code := range codes {
+			allowed[prefix][code] = true
+		}
+	}
+	middleAllowed := func(prefix, middle string) bool {
+		if codes, ok := allowed[prefix]; ok {
+			return codes[middle]
+		}
+		return middleSet[middle]
+	}
+
+	var out *bufio.Writer
+	if matchedOut != "" {
+		file, err := os.Create(matchedOut)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %v", matchedOut, err)
+		}
+		defer file.Close()
+		out = bufio.NewWriter(file)
+	}
+
+	numberRegex := regexp.MustCompile(fmt.Sprintf(`^\d{%d}$`, layout.totalLen()))
+	middleEnd := layout.prefixLen + layout.middleLen
+	var total, matched, malformed, unknownPrefix, unknownMiddle int
+	matchedByOperator := make(map[string]int)
+	// .bin文件按-format=binary的格式读取
+	var scanner interface {
+		Scan() bool
+		Text() string
+		Err() error
+	} = bufio.NewScanner(in)
+	if isBinaryNumbersFile(path) {
+		scanner = newBinaryNumberReader(in)
+	}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		total++
+		switch {
+		case !numberRegex.MatchString(line):
+			malformed++
+		case prefixOperators[line[:layout.prefixLen]] == "":
+			unknownPrefix++
+		case !middleAllowed(line[:layout.prefixLen], line[layout.prefixLen:middleEnd]):
+			unknownMiddle++
+		default:
+			matched++
+			matchedByOperator[prefixOperators[line[:layout.prefixLen]]]++
+			if out != nil {
+				if _, err := out.WriteString(line + "\n"); err != nil {
+					return fmt.Errorf("failed to write to %s: %v", matchedOut, err)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if out != nil {
+		if err := out.Flush(); err != nil {
+			return fmt.Errorf("failed to write to %s: %v", matchedOut, err)
+		}
+	}
+
+	fmt.Fprintf(infoOut, "\n🔍 Validation summary for %s:\n", path)
+	fmt.Fprintf(infoOut, "Total lines: %d | Matched: %d | Unmatched: %d\n", total, matched, total-matched)
+	fmt.Fprintf(infoOut, "Unmatched breakdown: wrong length: %d | unknown prefix: %d | middle code not configured: %d\n",
+		malformed, unknownPrefix, unknownMiddle)
+	var byOperator []string
+	for _, operator := range operatorOrder {
+		byOperator = append(byOperator, fmt.Sprintf("%s: %d", operatorLabel(operator), matchedByOperator[operator]))
+	}
+	fmt.Fprintf(infoOut, "Matched by operator: %s\n", strings.Join(byOperator, " | "))
+	if out != nil {
+		fmt.Fprintf(infoOut, "✅ Matched numbers written to %s\n", matchedOut)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 写入速度持续过低时返回的错误
+var errOutputStalled = errors.New("output stalled — disk may be failing")
+
+// 吞吐量看门狗：每个窗口统计写入的号码数，平均速度低于minRate（号码/秒）时判定输出停滞，
+// 之后所有写入都返回停滞错误，让生成流程正常收尾；写入本身卡死时再等一个窗口后调用giveUp
+type watchdog struct {
+	count   atomic.Int64
+	stalled chan struct{}
+	err     error // stalled关闭前写入
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+func startWatchdog(minRate float64, window time.Duration, giveUp func(err error)) *watchdog {
+	w := &watchdog{stalled: make(chan struct{}), done: make(chan struct{})}
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		var last int64
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+			n := w.count.Load()
+			rate := float64(n-last) / window.Seconds()
+			last = n
+			if rate >= minRate {
+				continue
+			}
+			w.err = fmt.Errorf("%w: %.1f numbers/s over the last %s, below -stall-rate %g", errOutputStalled, rate, window, minRate)
+			close(w.stalled)
+			select {
+			case <-w.done:
+			case <-time.After(window):
+				giveUp(w.err)
+			}
+			return
+		}
+	}()
+	return w
+}
+
+// 每写入一个号码调用一次
+func (w *watchdog) add() {
+	w.count.Add(1)
+}
+
+// 包装输出，判定停滞后写入直接返回错误
+func (w *watchdog) writer(dst io.Writer) io.Writer {
+	return stallWriter{dst, w}
+}
+
+// 生成结束后停止监控，可重复调用
+func (w *watchdog) stop() {
+	w.once.Do(func() {
+		close(w.done)
+		w.wg.Wait()
+	})
+}
+
+type stallWriter struct {
+	w   io.Writer
+	dog *watchdog
+}
+
+func (s stallWriter) Write(p []byte) (int, error) {
+	select {
+	case <-s.dog.stalled:
+		return 0, s.dog.err
+	default:
+	}
+	return s.w.Write(p)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// -workdir：创建目录（不存在时）并切换进去，之后配置文件、输出和日志等所有相对路径都相对于它，
+// 同时运行的多个实例各用一个目录即可互不覆盖
+func enterWorkdir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create working directory %s: %v", dir, err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("failed to change to working directory %s: %v", dir, err)
+	}
+	return nil
+}
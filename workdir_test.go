@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// -workdir创建目录，自动创建的配置、输出和日志都写在其中，当前目录不受影响
+func TestWorkdirFilesLandInDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv(envMiddleCodes, "")
+	var code int
+	captureStdout(t, func() {
+		code = runArgs(t, "generate", "-workdir", "runs/a", "-limit", "100", "-log", "run.log", "-force")
+	})
+	if code != 0 {
+		t.Fatalf("run exited with %d", code)
+	}
+	for _, name := range []string{"config.json", "phonedict.txt", "run.log"} {
+		if _, err := os.Stat(filepath.Join(dir, "runs", "a", name)); err != nil {
+			t.Errorf("%s is not in the working directory: %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s was written to the current directory", name)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "runs", "a", "phonedict.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 100 {
+		t.Fatalf("phonedict.txt has %d lines, want 100", lines)
+	}
+}
+
+func TestEnterWorkdirNotADirectory(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("file", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := enterWorkdir("file"); err == nil || !strings.Contains(err.Error(), "failed to create working directory file") {
+		t.Fatalf("enterWorkdir on a file returned %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// zip内记录本次运行信息的条目名
+const zipManifestName = "manifest.json"
+
+// -zip把所有输出文件作为条目写入一个zip，另加manifest.json；
+// zip已经压缩，远程上传和并行写入的多个部分也无法放进同一个zip
+func checkZipOptions(opts options) error {
+	if opts.zip == "" {
+		return nil
+	}
+	if !strings.HasSuffix(strings.ToLower(opts.zip), ".zip") {
+		return fmt.Errorf("-zip must name a .zip file, got %s", opts.zip)
+	}
+	if opts.compress != "" && opts.compress != "none" {
+		return fmt.Errorf("-zip cannot be used with -compress (zip entries are already compressed)")
+	}
+	if opts.outParallel != "" {
+		return fmt.Errorf("-zip cannot be used with -out-parallel")
+	}
+	if isRemoteOutput(opts.zip) {
+		return fmt.Errorf("-zip must be a local file")
+	}
+	if formats, err := parseFormats(opts.format); err == nil {
+		for _, format := range formats {
+			if format == "mask" || format == "ranges" {
+				return fmt.Errorf("-zip cannot be used with -format=%s", format)
+			}
+		}
+	}
+	return nil
+}
+
+// zip输出：先写入临时文件，finish时写入清单并重命名。zip同一时间只能写一个条目，
+// 第一个输出直接流式写入，其余输出先写入临时文件，提交时再复制进zip
+type zipArchive struct {
+	path      string
+	tmpPath   string
+	file      *os.File
+	zw        *zip.Writer
+	streaming bool // 已有输出直接写入zip
+}
+
+func createZip(path string, retries int) (*zipArchive, error) {
+	z := &zipArchive{path: path, tmpPath: path + ".tmp"}
+	file, err := os.Create(z.tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+	z.file = file
+	var dst io.Writer = file
+	if retries > 0 {
+		dst = &retryWriter{w: file, name: path, retries: retries}
+	}
+	z.zw = zip.NewWriter(dst)
+	return z, nil
+}
+
+// 在zip中为name创建输出，直接写入的条目file为nil
+func createZipEntry(z *zipArchive, name, format string) (*outputFile, error) {
+	o := &outputFile{format: format, path: name, archive: z}
+	if !z.streaming {
+		w, err := z.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to %s: %v", name, z.path, err)
+		}
+		z.streaming = true
+		o.w = &countingWriter{w: w}
+		return o, nil
+	}
+	file, err := os.CreateTemp(filepath.Dir(z.path), ".phonedict-zip-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+	o.file = file
+	o.w = &countingWriter{w: file}
+	return o, nil
+}
+
+// 把先写入临时文件的条目复制进zip；直接写入的条目在创建下一个条目或关闭zip时结束
+func (z *zipArchive) add(o *outputFile) error {
+	if o.file == nil {
+		return nil
+	}
+	defer os.Remove(o.file.Name())
+	defer o.file.Close()
+	if _, err := o.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read back %s: %v", o.file.Name(), err)
+	}
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: o.path, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to add %s to %s: %v", o.path, z.path, err)
+	}
+	if _, err := io.Copy(w, o.file); err != nil {
+		return fmt.Errorf("failed to add %s to %s: %v", o.path, z.path, err)
+	}
+	return nil
+}
+
+// manifest.json的内容
+type zipManifest struct {
+	Generated   string            `json:"generated"`
+	Command     []string          `json:"command"`
+	Numbers     int64             `json:"numbers"`
+	Prefixes    []string          `json:"prefixes"`
+	MiddleCodes []string          `json:"middleCodes"`
+	Layout      effectiveLayout   `json:"layout"`
+	Files       []zipManifestFile `json:"files"`
+}
+
+type zipManifestFile struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// 写入manifest.json，关闭zip并重命名为正式文件
+func (z *zipArchive) finish(manifest zipManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", zipManifestName, err)
+	}
+	w, err := z.zw.CreateHeader(&zip.FileHeader{Name: zipManifestName, Method: zip.Deflate, Modified: time.Now()})
+	if err == nil {
+		_, err = w.Write(append(data, '\n'))
+	}
+	if err == nil {
+		err = z.zw.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", z.path, err)
+	}
+	if err := z.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %v", z.tmpPath, err)
+	}
+	if err := os.Rename(z.tmpPath, z.path); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %v", z.tmpPath, z.path, err)
+	}
+	return nil
+}
+
+// 出错时删除不完整的zip
+func (z *zipArchive) abort() {
+	z.file.Close()
+	os.Remove(z.tmpPath)
+}